@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,55 +16,114 @@ import (
 	"go-user-service/internal"
 	"go-user-service/internal/pkg/config"
 	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/i18n"
 	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+	"go-user-service/migrations"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
-		return
 	}
 
 	// Initialize configuration
-	cfg := config.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if cfg != nil && cfg.Source.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Source.PrintConfig {
+		return
+	}
 
 	// Initialize logger
 	loggerInstance := logger.New(cfg.App.LogLevel, cfg.App.AppEnv)
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App)
+	if err != nil {
+		loggerInstance.Fatal("Failed to initialize tracing: ", err)
+	}
+
 	// Initialize database
-	db, err := database.NewPostgresConnection(cfg.Database)
+	db, err := database.NewPostgresConnection(cfg.Database, cfg.Secret("database.password"))
 	if err != nil {
 		loggerInstance.Fatal("Failed to connect to database: ", err)
 	}
 
+	// Send reads to the read replicas, if any
+	replicas, err := database.UseReplicas(db, cfg.Database, cfg.Secret("database.password"), loggerInstance)
+	if err != nil {
+		loggerInstance.Fatal("Failed to configure read replicas: ", err)
+	}
+
 	// Initialize Redis
-	redis, err := database.NewRedisConnection(cfg.Redis)
+	redis, err := database.NewRedisConnection(cfg.Redis, cfg.Secret("redis.password"))
 	if err != nil {
 		loggerInstance.Fatal("Failed to connect to Redis: ", err)
 	}
 
+	// Wait for the dependencies, then refuse to serve against an outdated schema
+	startup := func(ctx context.Context) error {
+		if err := database.WaitFor(ctx, cfg.Startup, loggerInstance,
+			database.PostgresDependency(db, cfg.Database.ConnectTimeout),
+			database.RedisDependency(redis, cfg.Redis.DialTimeout),
+		); err != nil {
+			return err
+		}
+		if err := checkSchema(db, cfg.Database.AllowPendingMigrations, loggerInstance); err != nil {
+			return fmt.Errorf("database schema check failed: %w", err)
+		}
+		return nil
+	}
+	if !cfg.Startup.Degraded {
+		if err := startup(context.Background()); err != nil {
+			loggerInstance.Fatal("Startup failed: ", err)
+		}
+	}
+
 	// Set Gin mode
 	if cfg.App.AppEnv == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Load message catalogs
+	catalog, err := i18n.New(cfg.App.DefaultLocale)
+	if err != nil {
+		loggerInstance.Fatal("Failed to load message catalogs: ", err)
+	}
+
 	// Initialize application
-	app := internal.NewApp(cfg, db, redis, *loggerInstance)
+	app := internal.NewApp(cfg, db, redis, *loggerInstance, catalog)
+	if cfg.Startup.Degraded {
+		app.Health.MarkStarting()
+	}
 
 	// Setup routes
 	router := app.SetupRoutes()
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.Server.APIPort),
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf(":%s", cfg.Server.APIPort),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
 	// Start server in goroutine
@@ -72,26 +134,126 @@ func main() {
 		}
 	}()
 
+	// Start gRPC server alongside HTTP
+	grpcServer := app.SetupGRPC()
+	if cfg.Startup.Degraded {
+		grpcServer.MarkStarting()
+	}
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Server.GRPCPort))
+	if err != nil {
+		loggerInstance.Fatal("Failed to listen for gRPC: ", err)
+	}
+	go func() {
+		loggerInstance.Info(fmt.Sprintf("Starting gRPC server on port %s", cfg.Server.GRPCPort))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			loggerInstance.Fatal("Failed to start gRPC server: ", err)
+		}
+	}()
+
+	// In degraded mode the servers answer liveness while the dependencies come
+	// up, a failure shuts them down like a signal would
+	startupCtx, stopStartup := context.WithCancel(context.Background())
+	defer stopStartup()
+	startupFailed := make(chan error, 1)
+	if cfg.Startup.Degraded {
+		go func() {
+			if err := startup(startupCtx); err != nil {
+				startupFailed <- err
+				return
+			}
+			app.Health.MarkStarted()
+			grpcServer.MarkStarted()
+			loggerInstance.Info("Dependencies are available, ready to serve")
+		}()
+	}
+
+	// Re-read secrets from their files or provider so rotations apply without a restart
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go cfg.WatchSecrets(watchCtx, loggerInstance)
+
+	// Take lagging or unreachable read replicas out of rotation
+	go replicas.Monitor(watchCtx)
+
+	// Drop users other replicas changed from the in-process cache
+	if app.UserCache != nil {
+		go app.UserCache.Listen(watchCtx)
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	exitCode := 0
+	select {
+	case <-quit:
+	case err := <-startupFailed:
+		loggerInstance.Error("Startup failed: ", err)
+		exitCode = 1
+	}
+	stopStartup()
 
 	loggerInstance.Info("Shutting down server...")
 
+	// Fail readiness first so load balancers stop routing new requests
+	app.Health.MarkShuttingDown()
+	grpcServer.MarkShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
+
 	// Create context with timeout for graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Shutdown server
+	// Shutdown servers, gRPC drains concurrently with HTTP
+	grpcDone := make(chan struct{})
+	go func() {
+		grpcServer.Shutdown(ctx)
+		close(grpcDone)
+	}()
 	if err := server.Shutdown(ctx); err != nil {
 		loggerInstance.Fatal("Server forced to shutdown: ", err)
 	}
+	<-grpcDone
 
 	// Close database connections
 	sqlDB, _ := db.DB()
 	sqlDB.Close()
+	replicas.Close()
 	redis.Close()
 
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		loggerInstance.Error("Failed to shutdown tracing: ", err)
+	}
+
 	loggerInstance.Info("Server exited")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// checkSchema fails when migrations are pending, unless explicitly allowed
+func checkSchema(db *gorm.DB, allowPending bool, log *logger.Logger) error {
+	migrator := database.NewMigrator(db)
+	if err := migrator.LoadMigrations(migrations.FS); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(database.WithPrimary(context.Background()), 10*time.Second)
+	defer cancel()
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if !allowPending {
+		return fmt.Errorf("%d migration(s) pending, run `migrate up` first (latest is %d_%s)",
+			len(pending), pending[len(pending)-1].Version, pending[len(pending)-1].Name)
+	}
+
+	log.Warn(fmt.Sprintf("Starting with %d pending migration(s) because DB_ALLOW_PENDING_MIGRATIONS is set", len(pending)))
+	return nil
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/migrations"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up            apply all pending migrations
+  down [N]      revert the last N migrations (default 1)
+  status        list migrations and whether they are applied
+  goto V        migrate up or down to version V
+  create NAME   create a new pair of empty migration files
+
+Flags:
+`
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory where create writes new migration files")
+	timeout := flag.Duration("timeout", 10*time.Minute, "maximum time to wait for the migration lock and run migrations")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only touches the filesystem, no database needed
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("create requires a NAME")
+		}
+		if err := create(*dir, args[1]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Initialize configuration, settings of the servers do not concern migrations
+	cfg, err := config.LoadDatabase(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresConnection(cfg.Database, nil)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	// The database may still be starting when migrations run as a deploy step
+	if err := database.WaitFor(context.Background(), cfg.Startup, logger.New(cfg.App.LogLevel, cfg.App.AppEnv),
+		database.PostgresDependency(db, cfg.Database.ConnectTimeout),
+	); err != nil {
+		log.Fatal(err)
+	}
+
+	migrator := database.NewMigrator(db)
+	if err := migrator.LoadMigrations(migrations.FS); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := run(ctx, migrator, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, migrator *database.Migrator, args []string) error {
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		report("Applied", done)
+		return err
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			parsed, err := strconv.Atoi(args[1])
+			if err != nil || parsed < 1 {
+				return fmt.Errorf("invalid N %q, must be a positive number", args[1])
+			}
+			n = parsed
+		}
+		done, err := migrator.Down(ctx, n)
+		report("Reverted", done)
+		return err
+	case "goto":
+		if len(args) != 2 {
+			return fmt.Errorf("goto requires a version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		done, err := migrator.Goto(ctx, version)
+		report("Migrated", done)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d  %-40s  %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func report(action string, versions []int64) {
+	if len(versions) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, v := range versions {
+		fmt.Printf("%s %06d\n", action, v)
+	}
+}
+
+// create writes empty up/down files numbered after the highest existing version
+func create(dir, name string) error {
+	name = strings.ToLower(name)
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid name %q, use lowercase letters, digits and underscores", name)
+	}
+
+	existing, err := database.LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", version, name, direction))
+		if err := os.WriteFile(path, []byte("-- "+direction+" migration for "+name+"\n"), 0o644); err != nil {
+			return err
+		}
+		fmt.Println("Created", path)
+	}
+	return nil
+}
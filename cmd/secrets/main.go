@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"go-user-service/internal/pkg/secrets"
+)
+
+const usage = `Usage: secrets [flags] <command>
+
+Manages the sealed secrets file read by SECRETS_PROVIDER=sealed.
+
+Commands:
+  keygen   print a new base64 key
+  seal     encrypt a JSON object of secret names to values from stdin to stdout
+  open     decrypt a sealed file from stdin and print its JSON to stdout
+
+Flags:
+`
+
+func main() {
+	keyFile := flag.String("key-file", "", "file holding the base64 key, defaults to $SECRETS_SEALED_KEY")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "keygen":
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(key)
+	case "seal":
+		var values map[string]string
+		if err := json.NewDecoder(os.Stdin).Decode(&values); err != nil {
+			log.Fatal("Invalid secrets JSON: ", err)
+		}
+		sealed, err := secrets.Seal(loadKey(*keyFile), values)
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(sealed)
+	case "open":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		values, err := secrets.Open(loadKey(*keyFile), data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(values)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func loadKey(path string) *secrets.Key {
+	encoded := os.Getenv("SECRETS_SEALED_KEY")
+	if path != "" {
+		var err error
+		if encoded, err = secrets.ReadFile(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	key, err := secrets.ParseKey(encoded)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return key
+}
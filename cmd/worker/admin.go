@@ -0,0 +1,272 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/health"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/middleware"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/pkg/secrets"
+	"go-user-service/internal/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminServer exposes the worker state and controls on WorkerPort
+type adminServer struct {
+	runtime   *worker.Runtime
+	queue     *worker.RedisQueue
+	scheduler *worker.Scheduler
+	health    *health.Checker
+	logger    *logger.Logger
+}
+
+// newAdminServer creates the worker admin HTTP server. Probes are public,
+// everything under /admin requires the X-Admin-Key header.
+func newAdminServer(port string, adminKey *secrets.Value, runtime *worker.Runtime, queue *worker.RedisQueue,
+	scheduler *worker.Scheduler, checker *health.Checker, logger *logger.Logger) *http.Server {
+	admin := &adminServer{
+		runtime:   runtime,
+		queue:     queue,
+		scheduler: scheduler,
+		health:    checker,
+		logger:    logger,
+	}
+
+	router := gin.New()
+	router.Use(middleware.LoggerMiddleware(*logger))
+	router.Use(middleware.Recovery(logger, nil))
+
+	router.GET("/health/live", admin.liveness)
+	router.GET("/health/ready", admin.readiness)
+
+	group := router.Group("/admin", middleware.AdminKey(adminKey))
+	{
+		group.GET("/queues", admin.listQueues)
+		group.GET("/queues/:queue/dead", admin.listDeadJobs)
+		group.POST("/queues/:queue/pause", admin.pauseQueue)
+		group.POST("/queues/:queue/resume", admin.resumeQueue)
+		group.POST("/queues/:queue/dead/requeue", admin.requeueDeadJobs)
+		group.POST("/queues/:queue/dead/:job_id/requeue", admin.requeueDeadJobs)
+		group.GET("/jobs/inflight", admin.listInFlight)
+		group.GET("/failures", admin.listFailures)
+
+		group.GET("/cron", admin.listTasks)
+		group.GET("/cron/:task/runs", admin.listRuns)
+	}
+
+	return &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// liveness only reports that the process is serving requests
+func (a *adminServer) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, a.health.Liveness())
+}
+
+// readiness probes dependencies and fails while shutting down
+func (a *adminServer) readiness(c *gin.Context) {
+	report := a.health.Readiness(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+	c.JSON(statusCode, report)
+}
+
+// listQueues returns the job counts of every queue declared by this worker
+func (a *adminServer) listQueues(c *gin.Context) {
+	names := a.runtime.Queues()
+	result := make([]worker.QueueStats, 0, len(names))
+	for _, name := range names {
+		stats, err := a.queue.Stats(c.Request.Context(), name)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		result = append(result, *stats)
+	}
+	response.OK(c, result)
+}
+
+// listDeadJobs returns the most recent dead-lettered jobs of a queue
+func (a *adminServer) listDeadJobs(c *gin.Context) {
+	name, ok := a.queueParam(c)
+	if !ok {
+		return
+	}
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := a.queue.DeadJobs(c.Request.Context(), name, limit)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, jobs)
+}
+
+// pauseQueue stops every replica from fetching jobs of a queue
+func (a *adminServer) pauseQueue(c *gin.Context) {
+	name, ok := a.queueParam(c)
+	if !ok {
+		return
+	}
+
+	if err := a.queue.Pause(c.Request.Context(), name); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	a.logger.WithField("queue", name).Warn("Queue paused")
+	a.respondStats(c, name)
+}
+
+// resumeQueue lets replicas fetch jobs of a paused queue again
+func (a *adminServer) resumeQueue(c *gin.Context) {
+	name, ok := a.queueParam(c)
+	if !ok {
+		return
+	}
+
+	if err := a.queue.Resume(c.Request.Context(), name); err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	a.logger.WithField("queue", name).Info("Queue resumed")
+	a.respondStats(c, name)
+}
+
+// requeueDeadJobs moves one dead job, or all of them when no job ID is given, back to the queue
+func (a *adminServer) requeueDeadJobs(c *gin.Context) {
+	name, ok := a.queueParam(c)
+	if !ok {
+		return
+	}
+	jobID := c.Param("job_id")
+
+	requeued, err := a.queue.RequeueDead(c.Request.Context(), name, jobID)
+	if errors.Is(err, worker.ErrConcurrentUpdate) {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeConflict, "Dead-letter list changed while requeueing, try again"))
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	if jobID != "" && requeued == 0 {
+		response.NotFound(c, "Dead job not found")
+		return
+	}
+
+	a.logger.WithFields(map[string]interface{}{
+		"queue":    name,
+		"job_id":   jobID,
+		"requeued": requeued,
+	}).Warn("Requeued dead jobs")
+	response.OK(c, gin.H{"requeued": requeued})
+}
+
+// listInFlight returns the jobs this replica is currently running
+func (a *adminServer) listInFlight(c *gin.Context) {
+	response.OK(c, a.runtime.InFlight())
+}
+
+// listFailures returns the most recent failed attempts across all queues
+func (a *adminServer) listFailures(c *gin.Context) {
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	failures, err := a.queue.RecentFailures(c.Request.Context(), limit)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, failures)
+}
+
+// listTasks returns every periodic task with its schedule and last run
+func (a *adminServer) listTasks(c *gin.Context) {
+	type taskStatus struct {
+		worker.PeriodicTask
+		LastRun *worker.TaskRun `json:"last_run"`
+	}
+
+	tasks := a.scheduler.Tasks()
+	result := make([]taskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		status := taskStatus{PeriodicTask: task}
+		runs, err := a.scheduler.Runs(c.Request.Context(), task.Name, 1)
+		if err != nil {
+			response.InternalError(c, err)
+			return
+		}
+		if len(runs) > 0 {
+			status.LastRun = &runs[0]
+		}
+		result = append(result, status)
+	}
+
+	response.OK(c, result)
+}
+
+// listRuns returns the recent runs of one task
+func (a *adminServer) listRuns(c *gin.Context) {
+	name := c.Param("task")
+	if _, ok := a.scheduler.Task(name); !ok {
+		response.NotFound(c, "Cron task not found")
+		return
+	}
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	runs, err := a.scheduler.Runs(c.Request.Context(), name, limit)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, runs)
+}
+
+func (a *adminServer) respondStats(c *gin.Context, name string) {
+	stats, err := a.queue.Stats(c.Request.Context(), name)
+	if err != nil {
+		response.InternalError(c, err)
+		return
+	}
+	response.OK(c, stats)
+}
+
+// queueParam returns the :queue parameter, rejecting queues this worker does not declare
+func (a *adminServer) queueParam(c *gin.Context) (string, bool) {
+	name := c.Param("queue")
+	if !slices.Contains(a.runtime.Queues(), name) {
+		response.NotFound(c, "Queue not found")
+		return "", false
+	}
+	return name, true
+}
+
+// limitParam parses the optional ?limit query, defaulting to 20
+func limitParam(c *gin.Context) (int64, bool) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil || limit < 1 || limit > 1000 {
+		response.BadRequest(c, "limit must be an integer between 1 and 1000")
+		return 0, false
+	}
+	return limit, true
+}
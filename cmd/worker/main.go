@@ -1,19 +1,37 @@
 package main
 
 import (
-	// "context"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"go-user-service/internal/domain"
+	"go-user-service/internal/maintenance"
+	"go-user-service/internal/notification"
 	"go-user-service/internal/pkg/config"
 	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/events"
+	"go-user-service/internal/pkg/health"
 	"go-user-service/internal/pkg/logger"
-	// "go-user-service/pkg/events"
+	"go-user-service/internal/pkg/mailer"
+	"go-user-service/internal/pkg/outbox"
+	"go-user-service/internal/pkg/tracing"
+	"go-user-service/internal/user"
+	"go-user-service/internal/webhook"
+	"go-user-service/internal/worker"
 
 	"github.com/joho/godotenv"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -23,34 +41,168 @@ func main() {
 	}
 
 	// Initialize configuration
-	cfg := config.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if cfg != nil && cfg.Source.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Source.PrintConfig {
+		return
+	}
 
 	// Initialize logger
 	loggerInstance := logger.New(cfg.App.LogLevel, cfg.App.AppEnv)
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App)
+	if err != nil {
+		loggerInstance.Fatal("Failed to initialize tracing: ", err)
+	}
+
 	// Initialize database
-	db, err := database.NewPostgresConnection(cfg.Database)
+	db, err := database.NewPostgresConnection(cfg.Database, cfg.Secret("database.password"))
 	if err != nil {
 		loggerInstance.Fatal("Failed to connect to database: ", err)
 	}
 
 	// Initialize Redis
-	redis, err := database.NewRedisConnection(cfg.Redis)
+	redis, err := database.NewRedisConnection(cfg.Redis, cfg.Secret("redis.password"))
 	if err != nil {
 		loggerInstance.Fatal("Failed to connect to Redis: ", err)
 	}
 
-	// Initialize event processor
-	// eventProcessor := events.NewProcessor(redis, loggerInstance)
+	// Wait for the dependencies before consuming anything
+	if err := database.WaitFor(context.Background(), cfg.Startup, loggerInstance,
+		database.PostgresDependency(db, cfg.Database.ConnectTimeout),
+		database.RedisDependency(redis, cfg.Redis.DialTimeout),
+	); err != nil {
+		loggerInstance.Fatal("Startup failed: ", err)
+	}
+
+	// Initialize event bus
+	eventBus := events.NewRedisStreams(redis, events.DefaultStreamOptions(), loggerInstance)
 
 	// Create context for graceful shutdown
-	// ctx, cancel := context.WithCancel(context.Background())
-	// defer cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Re-read secrets from their files or provider so rotations apply without
+	// a restart, only secrets requested before the watch starts are refreshed
+	adminKey := cfg.Secret("server.admin_api_key")
+	go cfg.WatchSecrets(ctx, loggerInstance)
+
+	// Initialize job runtime
+	opts := worker.DefaultOptions()
+	opts.PollInterval = cfg.Worker.PollInterval
+	jobQueue := worker.NewRedisQueue(redis)
+	runtime := worker.New(jobQueue, loggerInstance, opts)
+	runtime.AddQueue(worker.QueueConfig{
+		Name:        worker.DefaultQueue,
+		Concurrency: cfg.Worker.Concurrency,
+		Timeout:     cfg.Worker.JobTimeout,
+	})
+
+	// Initialize mailer
+	mailSender, err := mailer.NewSender(cfg.Mail)
+	if err != nil {
+		loggerInstance.Fatal("Failed to initialize mail sender: ", err)
+	}
+	mailRenderer, err := mailer.NewRenderer(cfg.Mail.DefaultLocale)
+	if err != nil {
+		loggerInstance.Fatal("Failed to parse email templates: ", err)
+	}
+	runtime.AddQueue(worker.QueueConfig{
+		Name:        "mail",
+		Concurrency: cfg.Worker.Concurrency,
+		Timeout:     cfg.Worker.JobTimeout,
+	})
+	mail := mailer.New(mailRenderer, mailSender, cfg.Mail.From, cfg.App.Name)
+	runtime.Register(mailer.JobType, "mail", cfg.Mail.MaxRetries, sendEmailJob(mail))
+
+	// Initialize notification fan-out
+	notificationRepo := notification.NewRepository(db)
+	channels := []notification.Channel{
+		notification.NewInAppChannel(notificationRepo),
+		notification.NewEmailChannel(runtime, user.NewRepository(db)),
+		notification.NewPushChannel(loggerInstance),
+	}
+	if cfg.Notification.WebhookURL != "" {
+		channels = append(channels, notification.NewWebhookChannel(cfg.Notification.WebhookURL, cfg.Notification.WebhookTimeout))
+	}
+	dispatcher := notification.NewDispatcher(notificationRepo, runtime, loggerInstance, channels...)
+	runtime.AddQueue(worker.QueueConfig{
+		Name:        "notifications",
+		Concurrency: cfg.Worker.Concurrency,
+		Timeout:     cfg.Worker.JobTimeout,
+	})
+	dispatcher.Register("notifications", cfg.Notification.MaxRetries)
+
+	// Initialize outgoing webhooks
+	webhooks := webhook.NewDeliverer(webhook.NewRepository(db), worker.NewClient(worker.NewRedisQueue(redis)),
+		cfg.Webhook.MaxRetries, webhook.DelivererOptions{
+			Timeout:         cfg.Webhook.Timeout,
+			DisableAfter:    cfg.Webhook.DisableAfter,
+			MaxResponseBody: cfg.Webhook.MaxResponseBody,
+			UserAgent:       cfg.App.Name + "-webhooks/" + cfg.App.Version,
+		}, loggerInstance)
+	runtime.AddQueue(worker.QueueConfig{
+		Name:        webhook.Queue,
+		Concurrency: cfg.Webhook.Concurrency,
+		Timeout:     cfg.Webhook.Timeout + 5*time.Second,
+	})
+	runtime.Register(webhook.DeliverJobType, webhook.Queue, cfg.Webhook.MaxRetries, webhooks.Handle)
+
+	// Initialize periodic maintenance tasks
+	scheduler, err := newScheduler(cfg, db, redis, loggerInstance)
+	if err != nil {
+		loggerInstance.Fatal("Failed to initialize cron scheduler: ", err)
+	}
+
+	// Long-running loops, drained on shutdown
+	var loops sync.WaitGroup
+	goLoop := func(fn func()) {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			fn()
+		}()
+	}
+
+	// Relay committed domain events from the outbox to the message bus
+	relay := outbox.NewRelay(db, outbox.NewBusPublisher(eventBus), loggerInstance,
+		cfg.Outbox.BatchSize, cfg.Outbox.PollInterval)
+	goLoop(func() { relay.Run(ctx) })
 
 	// Start background workers
-	// go startEmailWorker(ctx, eventProcessor, loggerInstance)
-	// go startNotificationWorker(ctx, eventProcessor, loggerInstance)
-	// go startUserEventWorker(ctx, eventProcessor, loggerInstance)
+	goLoop(func() { startNotificationWorker(ctx, eventBus, dispatcher, loggerInstance) })
+	goLoop(func() { startWebhookWorker(ctx, eventBus, webhooks, loggerInstance) })
+	goLoop(func() { startUserEventWorker(ctx, eventBus, loggerInstance) })
+	goLoop(func() { scheduler.Run(ctx) })
+	runtime.Start(ctx)
+
+	// Start admin server
+	checker := health.NewChecker(health.Info{
+		Name:    cfg.App.Name + "-worker",
+		Version: cfg.App.Version,
+		Commit:  cfg.App.Commit,
+	}, cfg.Server.HealthCheckTimeout, loggerInstance)
+	checker.Register("postgres", database.NewMigrator(db).HealthCheck)
+	checker.Register("redis", func(ctx context.Context) error {
+		return redis.Ping(ctx).Err()
+	})
+	adminSrv := newAdminServer(cfg.Server.WorkerPort, adminKey, runtime, jobQueue, scheduler, checker, loggerInstance)
+	go func() {
+		if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			loggerInstance.Fatal("Failed to start admin server: ", err)
+		}
+	}()
 
 	loggerInstance.Info("Worker started successfully")
 
@@ -60,77 +212,144 @@ func main() {
 	<-quit
 
 	loggerInstance.Info("Shutting down worker...")
+	checker.MarkShuttingDown()
+
+	// Stop fetching and give in-flight jobs time to finish
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.Worker.ShutdownTimeout)
+	defer drainCancel()
+
+	if abandoned := runtime.Shutdown(drainCtx); len(abandoned) > 0 {
+		loggerInstance.Warn(fmt.Sprintf("Abandoned %d in-flight job(s), they will be retried after their lease expires", len(abandoned)))
+	}
 
 	// Cancel context to stop all workers
-	// cancel()
+	cancel()
 
-	// Give workers time to finish
-	time.Sleep(5 * time.Second)
+	loopsDone := make(chan struct{})
+	go func() {
+		loops.Wait()
+		close(loopsDone)
+	}()
+	select {
+	case <-loopsDone:
+	case <-drainCtx.Done():
+		loggerInstance.Warn("Timed out waiting for event consumers to stop")
+	}
+
+	if err := adminSrv.Shutdown(drainCtx); err != nil {
+		loggerInstance.Error("Admin server forced to shutdown: ", err)
+	}
 
 	// Close database connections
 	sqlDB, _ := db.DB()
 	sqlDB.Close()
 	redis.Close()
 
+	// Flush pending spans
+	if err := shutdownTracing(context.Background()); err != nil {
+		loggerInstance.Error("Failed to shutdown tracing: ", err)
+	}
+
 	loggerInstance.Info("Worker exited")
 }
 
-// startEmailWorker handles email sending events
-// func startEmailWorker(ctx context.Context, processor *events.Processor, logger *logger.Logger) {
-	// logger.Info("Starting email worker...")
-	
-	// for {
-	// 	select {
-	// 	case <-ctx.Done():
-	// 		logger.Info("Email worker stopped")
-	// 		return
-	// 	default:
-	// 		// Process email events from Redis queue
-	// 		err := processor.ProcessEmailEvents(ctx)
-	// 		if err != nil {
-	// 			logger.Error("Error processing email events: ", err)
-	// 		}
-	// 		time.Sleep(1 * time.Second)
-	// 	}
-	// }
-// }
-
-// startNotificationWorker handles push notification events  
-// func startNotificationWorker(ctx context.Context, processor *events.Processor, logger *logger.Logger) {
-// 	logger.Info("Starting notification worker...")
-	
-// 	for {
-// 		select {
-// 		case <-ctx.Done():
-// 			logger.Info("Notification worker stopped")
-// 			return
-// 		default:
-// 			// Process notification events from Redis queue
-// 			err := processor.ProcessNotificationEvents(ctx)
-// 			if err != nil {
-// 				logger.Error("Error processing notification events: ", err)
-// 			}
-// 			time.Sleep(1 * time.Second)
-// 		}
-// 	}
-// }
-
-// // startUserEventWorker handles user-related events
-// func startUserEventWorker(ctx context.Context, processor *events.Processor, logger *logger.Logger) {
-// 	logger.Info("Starting user event worker...")
-	
-// 	for {
-// 		select {
-// 		case <-ctx.Done():
-// 			logger.Info("User event worker stopped")
-// 			return
-// 		default:
-// 			// Process user events from Redis queue
-// 			err := processor.ProcessUserEvents(ctx)
-// 			if err != nil {
-// 				logger.Error("Error processing user events: ", err)
-// 			}
-// 			time.Sleep(1 * time.Second)
-// 		}
-// 	}
-// }
\ No newline at end of file
+// newScheduler registers the maintenance tasks on their configured schedules
+func newScheduler(cfg *config.Config, db *gorm.DB, redis *goredis.Client, logger *logger.Logger) (*worker.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Cron.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRON_TIMEZONE: %w", err)
+	}
+
+	scheduler := worker.NewScheduler(redis, logger, worker.SchedulerOptions{
+		Location: location,
+		LeaseTTL: cfg.Cron.LeaseTTL,
+		History:  cfg.Cron.History,
+	})
+	var userCache *user.Cache
+	if cfg.UserCache.Enabled {
+		userCache = user.NewCache(redis, cfg.UserCache, logger)
+	}
+	tasks := maintenance.NewTasks(db, redis, userCache, logger, cfg.Cron.DeletedUserRetention, cfg.Cron.OutboxRetention)
+
+	for _, task := range []struct {
+		name    string
+		spec    string
+		timeout time.Duration
+		run     worker.TaskFunc
+	}{
+		{"purge_expired_tokens", cfg.Cron.PurgeTokensSchedule, 5 * time.Minute, tasks.PurgeExpiredTokens},
+		{"purge_deleted_users", cfg.Cron.PurgeUsersSchedule, 30 * time.Minute, tasks.PurgeDeletedUsers},
+		{"clean_outbox", cfg.Cron.CleanOutboxSchedule, 10 * time.Minute, tasks.CleanOutbox},
+		{"aggregate_user_stats", cfg.Cron.UserStatsSchedule, time.Minute, tasks.AggregateUserStats},
+	} {
+		if task.spec == "" {
+			continue
+		}
+		if err := scheduler.Add(task.name, task.spec, task.timeout, task.run); err != nil {
+			return nil, err
+		}
+	}
+
+	return scheduler, nil
+}
+
+// sendEmailJob renders and delivers an email.send job
+func sendEmailJob(m *mailer.Mailer) worker.Handler {
+	return func(ctx context.Context, job *worker.Job) error {
+		var email mailer.Email
+		if err := job.Decode(&email); err != nil {
+			return worker.Permanent(err)
+		}
+
+		err := m.Send(ctx, email)
+		if mailer.IsPermanent(err) {
+			return worker.Permanent(err)
+		}
+		return err
+	}
+}
+
+// startNotificationWorker fans user events out to the notification channels
+func startNotificationWorker(ctx context.Context, bus events.Subscriber, dispatcher *notification.Dispatcher, logger *logger.Logger) {
+	logger.Info("Starting notification worker...")
+
+	if err := bus.Subscribe(ctx, domain.UserEventsTopic, "notification-worker", dispatcher.HandleEvent); err != nil {
+		logger.Error("Notification worker failed: ", err)
+	}
+
+	logger.Info("Notification worker stopped")
+}
+
+// startWebhookWorker enqueues deliveries of user events to subscribed webhook endpoints
+func startWebhookWorker(ctx context.Context, bus events.Subscriber, deliverer *webhook.Deliverer, logger *logger.Logger) {
+	logger.Info("Starting webhook worker...")
+
+	if err := bus.Subscribe(ctx, domain.UserEventsTopic, "webhook-worker", deliverer.HandleEvent); err != nil {
+		logger.Error("Webhook worker failed: ", err)
+	}
+
+	logger.Info("Webhook worker stopped")
+}
+
+// startUserEventWorker handles user-related events
+func startUserEventWorker(ctx context.Context, bus events.Subscriber, logger *logger.Logger) {
+	logger.Info("Starting user event worker...")
+
+	err := bus.Subscribe(ctx, domain.UserEventsTopic, "user-event-worker", func(ctx context.Context, msg *events.Message) error {
+		var event domain.Event
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			return err
+		}
+
+		logger.LogBusinessEvent(string(event.Type), event.AggregateID, map[string]interface{}{
+			"event_id":    event.ID,
+			"occurred_at": event.OccurredAt,
+		})
+		return nil
+	})
+	if err != nil {
+		logger.Error("User event worker failed: ", err)
+	}
+
+	logger.Info("User event worker stopped")
+}
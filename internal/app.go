@@ -1,11 +1,23 @@
 package internal
 
 import (
+	"context"
+	"net/http"
+
+	"go-user-service/internal/grpcserver"
+	"go-user-service/internal/pkg/auth"
 	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/health"
+	"go-user-service/internal/pkg/i18n"
 	"go-user-service/internal/pkg/logger"
 	"go-user-service/internal/pkg/middleware"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/pkg/validator"
+	"go-user-service/internal/user"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
@@ -16,50 +28,140 @@ type App struct {
 	DB     *gorm.DB
 	Redis  *redis.Client
 	Logger logger.Logger
+	Health *health.Checker
+	I18n   *i18n.Catalog
+	// ErrorReporter optionally receives recovered panics, set it before SetupRoutes
+	ErrorReporter middleware.ErrorReporter
+	// UserCache serves user lookups from Redis, nil when disabled
+	UserCache *user.Cache
 }
 
 // NewApp creates a new application instance
-func NewApp(cfg *config.Config, db *gorm.DB, redis *redis.Client, logger logger.Logger) *App {
+func NewApp(cfg *config.Config, db *gorm.DB, redis *redis.Client, logger logger.Logger, catalog *i18n.Catalog) *App {
+	// Bound requests are checked with the custom rules and report translated field-level errors
+	v := validator.New()
+	if err := v.RegisterTranslations(catalog); err != nil {
+		logger.Fatal("Failed to register validation messages: ", err)
+	}
+	binding.Validator = v
+
+	response.Configure(response.Config{
+		ErrorFormat:        cfg.Server.ErrorFormat,
+		ProblemTypeBaseURL: cfg.Server.ProblemTypeBaseURL,
+	})
 
-	return &App{
+	app := &App{
 		Config: cfg,
 		DB:     db,
 		Redis:  redis,
 		Logger: logger,
+		I18n:   catalog,
 	}
-}
 
-// Health check handler
-func (a *App) healthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status":  "healthy",
-		"service": "user-service",
+	if cfg.UserCache.Enabled {
+		app.UserCache = user.NewCache(redis, cfg.UserCache, &app.Logger)
+	}
+
+	app.Health = health.NewChecker(health.Info{
+		Name:    cfg.App.Name,
+		Version: cfg.App.Version,
+		Commit:  cfg.App.Commit,
+	}, cfg.Server.HealthCheckTimeout, &app.Logger)
+	app.Health.Register("postgres", database.NewMigrator(db).HealthCheck)
+	app.Health.Register("redis", func(ctx context.Context) error {
+		return redis.Ping(ctx).Err()
 	})
+
+	return app
+}
+
+// jwtKeys verifies tokens with the current JWT secret and, for the lifetime
+// of a token after a rotation, the previous one
+func (a *App) jwtKeys() auth.Keys {
+	secret, ttl := a.Config.Secret("jwt.secret"), a.Config.JWT.ExpiresIn
+	return func() []string {
+		return secret.Versions(ttl)
+	}
+}
+
+// Liveness handler, only reports that the process is serving requests
+func (a *App) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Health.Liveness())
+}
+
+// Readiness handler, probes dependencies and fails while shutting down
+func (a *App) readiness(c *gin.Context) {
+	report := a.Health.Readiness(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+	c.JSON(statusCode, report)
 }
 
 func (a *App) SetupRoutes() *gin.Engine {
 	router := gin.New()
 
 	// Middleware
+	router.Use(middleware.Tracing())
+	router.Use(middleware.RequestID())
+	// Recover early so panics in later middleware are reported with the request ID
+	router.Use(middleware.Recovery(&a.Logger, a.ErrorReporter))
+	router.Use(middleware.Locale(a.I18n))
+	router.Use(middleware.BodyLimit(a.Config.Server.MaxBodyBytes))
+	router.Use(middleware.PrimaryForWrites())
 	router.Use(gin.Logger())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(middleware.LoggerMiddleware(a.Logger))
-	router.Use(gin.Recovery())
-	// router.Use(middleware.RequestIDMiddleware())
 
-	// Health check endpoint
-	router.GET("/health", a.healthCheck)
+	// Health check endpoints
+	router.GET("/health", a.liveness)
+	router.GET("/health/live", a.liveness)
+	router.GET("/health/ready", a.readiness)
 
 	// dependency injection for handlers
-	userHandler := diUser(a.DB)
+	userService := diUserService(a.DB, a.UserCache)
+	userHandler := user.NewHandler(userService)
+	notificationHandler := diNotification(a.DB)
+	webhookHandler := diWebhook(a.DB, a.Redis, a.Config.Webhook.MaxRetries)
 
-	// API versioning
-	v1 := router.Group("/api/v1")
+	// API versioning, unavailable until the startup checks pass
+	v1 := router.Group("/api/v1", middleware.RequireStarted(a.Health))
+
+	// Error catalog, problem type URIs resolve to these routes
+	v1.GET("/errors", response.ErrorCatalog)
+	v1.GET("/errors/:type", response.ErrorType)
 
 	// Auth routes
 
 	// User routes
 	userHandler.RegisRoutes(v1)
 
+	// Authenticated routes
+	authed := v1.Group("", middleware.Auth(a.jwtKeys()),
+		middleware.UserLocale(a.I18n, func(ctx context.Context, userID uint) (string, error) {
+			locale, appErr := userService.Locale(ctx, userID)
+			if appErr != nil {
+				return "", appErr
+			}
+			return locale, nil
+		}))
+	userHandler.RegisAuthRoutes(authed)
+	notificationHandler.RegisRoutes(authed)
+
+	// Admin routes
+	admin := v1.Group("/admin", middleware.AdminKey(a.Config.Secret("server.admin_api_key")))
+	userHandler.RegisAdminRoutes(admin)
+	webhookHandler.RegisRoutes(admin)
+
 	return router
 }
+
+// SetupGRPC builds the gRPC server, sharing services with the HTTP routes
+func (a *App) SetupGRPC() *grpcserver.Server {
+	return grpcserver.New(grpcserver.Options{
+		JWTKeys: a.jwtKeys(),
+		Logger:  &a.Logger,
+	}, diUserService(a.DB, a.UserCache))
+}
@@ -1,15 +1,38 @@
 package internal
 
 import (
+	"go-user-service/internal/notification"
+	"go-user-service/internal/pkg/outbox"
 	"go-user-service/internal/user"
+	"go-user-service/internal/webhook"
+	"go-user-service/internal/worker"
 
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func diUser(db *gorm.DB) *user.Handler {
+func diUserService(db *gorm.DB, cache *user.Cache) user.Service {
 	userRepo := user.NewRepository(db)
-	userService := user.NewService(userRepo)
-	userHandler := user.NewHandler(userService)
+	if cache != nil {
+		userRepo = cache.Wrap(userRepo)
+	}
+	userService := user.NewService(db, userRepo, outbox.NewStore())
 
-	return userHandler
+	return userService
+}
+
+func diNotification(db *gorm.DB) *notification.Handler {
+	notificationRepo := notification.NewRepository(db)
+	notificationService := notification.NewService(notificationRepo)
+	notificationHandler := notification.NewHandler(notificationService)
+
+	return notificationHandler
+}
+
+func diWebhook(db *gorm.DB, redis *redis.Client, maxRetries int) *webhook.Handler {
+	webhookRepo := webhook.NewRepository(db)
+	webhookService := webhook.NewService(webhookRepo, worker.NewClient(worker.NewRedisQueue(redis)), maxRetries)
+	webhookHandler := webhook.NewHandler(webhookService)
+
+	return webhookHandler
 }
@@ -0,0 +1,113 @@
+// Package domain defines the user domain events shared between the API,
+// the outbox relay and worker consumers.
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType identifies a domain event
+type EventType string
+
+const (
+	UserRegistered      EventType = "user.registered"
+	UserEmailVerified   EventType = "user.email_verified"
+	UserPasswordChanged EventType = "user.password_changed"
+	UserProfileUpdated  EventType = "user.profile_updated"
+	UserDeleted         EventType = "user.deleted"
+)
+
+// AggregateUser is the aggregate type of every user event
+const AggregateUser = "user"
+
+// UserEventsTopic is the event bus topic carrying user events
+var UserEventsTopic = Topic(AggregateUser)
+
+// Topic returns the event bus topic for an aggregate type
+func Topic(aggregateType string) string {
+	return "events:" + aggregateType
+}
+
+// EventTypes lists every known event type
+var EventTypes = []EventType{
+	UserRegistered,
+	UserEmailVerified,
+	UserPasswordChanged,
+	UserProfileUpdated,
+	UserDeleted,
+}
+
+// Event is an immutable fact about an aggregate
+type Event struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEvent creates an event with a fresh ID and the payload encoded as JSON
+func NewEvent(eventType EventType, aggregateType, aggregateID string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return Event{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       data,
+	}, nil
+}
+
+// Decode unmarshals the event payload into v
+func (e Event) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// UserRegisteredPayload is emitted after a user account is created
+type UserRegisteredPayload struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// UserEmailVerifiedPayload is emitted after a user confirms their email address
+type UserEmailVerifiedPayload struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// UserPasswordChangedPayload is emitted after a password change or reset
+type UserPasswordChangedPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+// UserProfileUpdatedPayload is emitted after profile fields change
+type UserProfileUpdatedPayload struct {
+	UserID        uint     `json:"user_id"`
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// UserDeletedPayload is emitted after a user account is deleted
+type UserDeletedPayload struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// NewUserEvent creates a user aggregate event
+func NewUserEvent(eventType EventType, userID uint, payload interface{}) (Event, error) {
+	return NewEvent(eventType, AggregateUser, strconv.FormatUint(uint64(userID), 10), payload)
+}
@@ -0,0 +1,63 @@
+package grpcserver
+
+import (
+	"go-user-service/internal/pkg/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCode maps an application error code to its gRPC equivalent
+func statusCode(code errors.ErrorCode) codes.Code {
+	switch code {
+	case errors.ErrCodeValidation:
+		return codes.InvalidArgument
+	case errors.ErrCodeNotFound:
+		return codes.NotFound
+	case errors.ErrCodeAlreadyExists:
+		return codes.AlreadyExists
+	case errors.ErrCodeConflict:
+		return codes.Aborted
+	case errors.ErrCodePreconditionFailed, errors.ErrCodeUnprocessable:
+		return codes.FailedPrecondition
+	case errors.ErrCodeUnauthorized:
+		return codes.Unauthenticated
+	case errors.ErrCodeForbidden:
+		return codes.PermissionDenied
+	case errors.ErrCodeRateLimited:
+		return codes.ResourceExhausted
+	case errors.ErrCodePayloadTooLarge, errors.ErrCodeUnsupportedMedia:
+		return codes.InvalidArgument
+	case errors.ErrCodeCanceled:
+		return codes.Canceled
+	case errors.ErrCodeNotImplemented:
+		return codes.Unimplemented
+	case errors.ErrCodeTimeout:
+		return codes.DeadlineExceeded
+	case errors.ErrCodeExternal, errors.ErrCodeUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus converts err into a gRPC status error. Internal details of
+// server-side failures are not sent to the client.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if appErr := errors.Classify(err); appErr != nil {
+		code := statusCode(appErr.Code)
+		message := appErr.Message
+		if appErr.Details != "" && code != codes.Internal {
+			message += ": " + appErr.Details
+		}
+		return status.Error(code, message)
+	}
+	return status.Error(codes.Internal, "Internal server error")
+}
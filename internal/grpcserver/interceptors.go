@@ -0,0 +1,192 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is read from incoming metadata and echoed in the response header
+const RequestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID assigned by the request ID interceptor
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// metadataCarrier adapts gRPC metadata to the OpenTelemetry propagator
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) { metadata.MD(c).Set(key, value) }
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// requestIDInterceptor reuses the caller's x-request-id or generates one
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		id := metadataCarrier(md).Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+
+		return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+	}
+}
+
+// tracingInterceptor continues the caller's W3C trace and starts a server span
+func tracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.TextMapCarrier(metadataCarrier(md)))
+
+		service, method := splitMethod(info.FullMethod)
+		ctx, span := tracing.Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.RPCSystemGRPC,
+				semconv.RPCService(service),
+				semconv.RPCMethod(method),
+			),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if err != nil && code != codes.NotFound && code != codes.InvalidArgument && code != codes.AlreadyExists {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		return resp, err
+	}
+}
+
+// loggingInterceptor logs every call with its status code and duration
+func loggingInterceptor(l *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		entry := l.WithContext(ctx).WithFields(map[string]interface{}{
+			"type":       "grpc_request",
+			"method":     info.FullMethod,
+			"code":       code.String(),
+			"duration":   time.Since(start).Milliseconds(),
+			"request_id": RequestID(ctx),
+		})
+		switch code {
+		case codes.OK:
+			entry.Info("gRPC request completed")
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			entry.WithError(err).Error("gRPC request failed")
+		default:
+			entry.WithError(err).Warn("gRPC request failed")
+		}
+		return resp, err
+	}
+}
+
+// recoveryInterceptor turns handler panics into codes.Internal
+func recoveryInterceptor(l *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				l.LogError(fmt.Errorf("panic: %v", rec), "grpc_recovery", map[string]interface{}{
+					"method":     info.FullMethod,
+					"request_id": RequestID(ctx),
+					"stack":      string(debug.Stack()),
+				})
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// startingInterceptor rejects calls with codes.Unavailable while starting is
+// set, except for the methods in allowed
+func startingInterceptor(starting *atomic.Bool, allowed map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if starting.Load() && !allowed[info.FullMethod] {
+			return nil, status.Error(codes.Unavailable, "Service is starting")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authInterceptor requires a bearer token in the "authorization" metadata,
+// except for the methods in public
+func authInterceptor(keys auth.Keys, public map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		token, found := strings.CutPrefix(metadataCarrier(md).Get("authorization"), "Bearer ")
+		if !found || token == "" {
+			return nil, status.Error(codes.Unauthenticated, "Missing bearer token")
+		}
+
+		userID, err := auth.Verify(keys, token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Invalid or expired token")
+		}
+		return handler(auth.WithUserID(ctx, userID), req)
+	}
+}
+
+// errorInterceptor converts application errors returned by handlers to gRPC statuses
+func errorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(err)
+	}
+}
+
+// splitMethod splits "/package.Service/Method" into service and method
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return fullMethod, ""
+}
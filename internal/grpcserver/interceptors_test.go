@@ -0,0 +1,38 @@
+package grpcserver
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	userv1 "go-user-service/internal/gen/user/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestStartingInterceptor(t *testing.T) {
+	starting := new(atomic.Bool)
+	interceptor := startingInterceptor(starting, healthMethods)
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	call := func(method string) codes.Code {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return status.Code(err)
+	}
+
+	starting.Store(true)
+	if got := call(userv1.UserService_GetUser_FullMethodName); got != codes.Unavailable {
+		t.Errorf("while starting, GetUser = %s, want Unavailable", got)
+	}
+	if got := call(healthpb.Health_Check_FullMethodName); got != codes.OK {
+		t.Errorf("while starting, Health/Check = %s, want OK", got)
+	}
+
+	starting.Store(false)
+	if got := call(userv1.UserService_GetUser_FullMethodName); got != codes.OK {
+		t.Errorf("once started, GetUser = %s, want OK", got)
+	}
+}
@@ -0,0 +1,117 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	userv1 "go-user-service/internal/gen/user/v1"
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Options configures the gRPC server
+type Options struct {
+	// JWTKeys verify the bearer tokens of authenticated methods
+	JWTKeys auth.Keys
+	Logger  *logger.Logger
+}
+
+// publicMethods can be called without a bearer token
+var publicMethods = map[string]bool{
+	userv1.UserService_Register_FullMethodName: true,
+	healthpb.Health_Check_FullMethodName:       true,
+	healthpb.Health_Watch_FullMethodName:       true,
+}
+
+// healthMethods are served while the server is starting
+var healthMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_Watch_FullMethodName: true,
+}
+
+// Server is the gRPC server of the API process
+type Server struct {
+	grpc     *grpc.Server
+	health   *health.Server
+	starting *atomic.Bool
+}
+
+// New builds a gRPC server exposing the user service, the standard health
+// checking protocol and server reflection
+func New(opts Options, users user.Service) *Server {
+	starting := new(atomic.Bool)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		recoveryInterceptor(opts.Logger),
+		requestIDInterceptor(),
+		tracingInterceptor(),
+		loggingInterceptor(opts.Logger),
+		startingInterceptor(starting, healthMethods),
+		authInterceptor(opts.JWTKeys, publicMethods),
+		errorInterceptor(),
+	))
+
+	userv1.RegisterUserServiceServer(server, newUserServer(users))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(userv1.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return &Server{grpc: server, health: healthServer, starting: starting}
+}
+
+// Serve accepts connections on lis until the server is stopped
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// MarkStarting reports NOT_SERVING to health checks and rejects every
+// other call with codes.Unavailable until MarkStarted
+func (s *Server) MarkStarting() {
+	s.starting.Store(true)
+	s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// MarkStarted reports SERVING to health checks, unless shutdown has begun
+func (s *Server) MarkStarted() {
+	s.starting.Store(false)
+	s.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+}
+
+func (s *Server) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(userv1.UserService_ServiceDesc.ServiceName, status)
+}
+
+// MarkShuttingDown reports NOT_SERVING to health checks while calls are still served
+func (s *Server) MarkShuttingDown() {
+	s.health.Shutdown()
+}
+
+// Shutdown reports NOT_SERVING to health checks and waits for in-flight
+// calls to finish, forcing the remaining ones closed when ctx expires
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-done
+	}
+}
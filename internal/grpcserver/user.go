@@ -0,0 +1,164 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	userv1 "go-user-service/internal/gen/user/v1"
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/user"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	maxBatchSize    = 500
+)
+
+// userServer exposes user.Service over gRPC
+type userServer struct {
+	userv1.UnimplementedUserServiceServer
+	service user.Service
+}
+
+func newUserServer(service user.Service) *userServer {
+	return &userServer{service: service}
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+	if req.GetId() == 0 {
+		return nil, errors.New(errors.ErrCodeValidation, "id is required")
+	}
+
+	found, appErr := s.service.GetByID(ctx, uint(req.GetId()))
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &userv1.GetUserResponse{User: toProto(found)}, nil
+}
+
+func (s *userServer) GetUserByEmail(ctx context.Context, req *userv1.GetUserByEmailRequest) (*userv1.GetUserByEmailResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, errors.New(errors.ErrCodeValidation, "email is required")
+	}
+
+	found, appErr := s.service.GetByEmail(ctx, req.GetEmail())
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &userv1.GetUserByEmailResponse{User: toProto(found)}, nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, errors.New(errors.ErrCodeValidation, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	afterID, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeValidation, "Invalid page_token")
+	}
+
+	result, appErr := s.service.List(ctx, afterID, pageSize)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	resp := &userv1.ListUsersResponse{Users: make([]*userv1.User, 0, len(result.Users))}
+	for i := range result.Users {
+		resp.Users = append(resp.Users, toProto(&result.Users[i]))
+	}
+	if result.NextAfterID != 0 {
+		resp.NextPageToken = encodePageToken(result.NextAfterID)
+	}
+	return resp, nil
+}
+
+func (s *userServer) Register(ctx context.Context, req *userv1.RegisterRequest) (*userv1.RegisterResponse, error) {
+	// Apply the same binding rules as the HTTP endpoint
+	input := user.CreateUserRequest{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeValidation, "Invalid registration request")
+	}
+
+	created, appErr := s.service.Register(ctx, input)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &userv1.RegisterResponse{User: toProto(created)}, nil
+}
+
+func (s *userServer) BatchGetUsers(ctx context.Context, req *userv1.BatchGetUsersRequest) (*userv1.BatchGetUsersResponse, error) {
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, errors.New(errors.ErrCodeValidation, fmt.Sprintf("At most %d ids can be requested at once", maxBatchSize))
+	}
+
+	ids := make([]uint, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, uint(id))
+	}
+
+	users, appErr := s.service.BatchGet(ctx, ids)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	resp := &userv1.BatchGetUsersResponse{Users: make([]*userv1.User, 0, len(users))}
+	found := make(map[uint64]bool, len(users))
+	for i := range users {
+		resp.Users = append(resp.Users, toProto(&users[i]))
+		found[uint64(users[i].ID)] = true
+	}
+	for _, id := range req.GetIds() {
+		if !found[id] {
+			resp.MissingIds = append(resp.MissingIds, id)
+			found[id] = true
+		}
+	}
+	return resp, nil
+}
+
+func toProto(u *user.UserResponse) *userv1.User {
+	return &userv1.User{
+		Id:        uint64(u.ID),
+		Username:  u.Username,
+		Email:     u.Email,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}
+}
+
+// Page tokens are opaque to clients, they currently wrap the last returned ID
+func encodePageToken(afterID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(afterID), 10)))
+}
+
+func decodePageToken(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,162 @@
+package maintenance
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/user"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// UserStatsKey is the Redis hash holding the aggregated user statistics
+const UserStatsKey = "stats:users"
+
+// deleteBatchSize bounds each DELETE so a large backlog does not hold long locks
+const deleteBatchSize = 1000
+
+// Tasks holds the periodic maintenance tasks run by the worker scheduler
+type Tasks struct {
+	db     *gorm.DB
+	redis  *redis.Client
+	logger *logger.Logger
+	// userCache forgets purged users, nil when the cache is disabled
+	userCache *user.Cache
+
+	deletedUserRetention time.Duration
+	outboxRetention      time.Duration
+}
+
+// NewTasks creates the maintenance tasks
+func NewTasks(db *gorm.DB, redis *redis.Client, userCache *user.Cache, logger *logger.Logger, deletedUserRetention, outboxRetention time.Duration) *Tasks {
+	return &Tasks{
+		db:                   db,
+		redis:                redis,
+		logger:               logger,
+		userCache:            userCache,
+		deletedUserRetention: deletedUserRetention,
+		outboxRetention:      outboxRetention,
+	}
+}
+
+// PurgeExpiredTokens deletes verification and reset tokens past their expiry
+func (t *Tasks) PurgeExpiredTokens(ctx context.Context) error {
+	n, err := t.purge(ctx, "user_tokens", "expires_at < NOW()")
+	if err != nil {
+		return err
+	}
+	t.logger.LogBusinessEvent("expired_tokens_purged", "", map[string]interface{}{"count": n})
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes users soft-deleted longer than the retention
+// period and drops them from the user cache
+func (t *Tasks) PurgeDeletedUsers(ctx context.Context) error {
+	cutoff := time.Now().Add(-t.deletedUserRetention)
+	query := purgeQuery("users", "deleted_at IS NOT NULL AND deleted_at < ?") + " RETURNING id, email"
+
+	var n int64
+	for {
+		var purged []user.User
+		start := time.Now()
+		err := t.db.WithContext(ctx).Raw(query, cutoff).Scan(&purged).Error
+		t.logger.LogDBOperation("DELETE", "users", time.Since(start).Milliseconds(), err)
+		if err != nil {
+			return err
+		}
+		if t.userCache != nil {
+			t.userCache.Forget(ctx, purged...)
+		}
+		n += int64(len(purged))
+		if len(purged) < deleteBatchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	t.logger.LogBusinessEvent("deleted_users_purged", "", map[string]interface{}{
+		"count":  n,
+		"cutoff": cutoff,
+	})
+	return nil
+}
+
+// CleanOutbox deletes outbox rows delivered longer than the retention period
+func (t *Tasks) CleanOutbox(ctx context.Context) error {
+	cutoff := time.Now().Add(-t.outboxRetention)
+	n, err := t.purge(ctx, "outbox", "delivered_at IS NOT NULL AND delivered_at < ?", cutoff)
+	if err != nil {
+		return err
+	}
+	t.logger.LogBusinessEvent("outbox_cleaned", "", map[string]interface{}{
+		"count":  n,
+		"cutoff": cutoff,
+	})
+	return nil
+}
+
+// userStats is the row returned by the aggregation query
+type userStats struct {
+	Total        int64
+	Active       int64
+	Deleted      int64
+	Registered24 int64 `gorm:"column:registered_24h"`
+	Registered7d int64 `gorm:"column:registered_7d"`
+}
+
+// AggregateUserStats counts users and stores the result in the stats:users hash
+func (t *Tasks) AggregateUserStats(ctx context.Context) error {
+	var stats userStats
+	err := t.db.WithContext(ctx).Raw(`
+		SELECT
+			COUNT(*)                                                      AS total,
+			COUNT(*) FILTER (WHERE deleted_at IS NULL)                    AS active,
+			COUNT(*) FILTER (WHERE deleted_at IS NOT NULL)                AS deleted,
+			COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 day') AS registered_24h,
+			COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '7 day') AS registered_7d
+		FROM users`).Scan(&stats).Error
+	if err != nil {
+		return err
+	}
+
+	return t.redis.HSet(ctx, UserStatsKey, map[string]interface{}{
+		"total":          stats.Total,
+		"active":         stats.Active,
+		"deleted":        stats.Deleted,
+		"registered_24h": stats.Registered24,
+		"registered_7d":  stats.Registered7d,
+		"updated_at":     strconv.FormatInt(time.Now().Unix(), 10),
+	}).Err()
+}
+
+// purge deletes matching rows of table in batches, returning how many were removed
+func (t *Tasks) purge(ctx context.Context, table, where string, args ...interface{}) (int64, error) {
+	query := purgeQuery(table, where)
+
+	var total int64
+	for {
+		start := time.Now()
+		result := t.db.WithContext(ctx).Exec(query, args...)
+		t.logger.LogDBOperation("DELETE", table, time.Since(start).Milliseconds(), result.Error)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < deleteBatchSize {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
+
+// purgeQuery deletes one batch of the rows of table matching where
+func purgeQuery(table, where string) string {
+	return "DELETE FROM " + table + " WHERE ctid IN (SELECT ctid FROM " + table +
+		" WHERE " + where + " LIMIT " + strconv.Itoa(deleteBatchSize) + ")"
+}
@@ -0,0 +1,169 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/mailer"
+	"go-user-service/internal/user"
+	"go-user-service/internal/worker"
+)
+
+// Channel delivers a notification to a user over one medium
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, n *Notification) error
+}
+
+// InAppChannel stores the notification in the user's inbox
+type InAppChannel struct {
+	repo Repository
+}
+
+// NewInAppChannel creates a new in-app channel
+func NewInAppChannel(repo Repository) *InAppChannel {
+	return &InAppChannel{repo: repo}
+}
+
+func (c *InAppChannel) Name() string { return ChannelInApp }
+
+func (c *InAppChannel) Deliver(ctx context.Context, n *Notification) error {
+	stored := *n
+	stored.ID = 0
+	return c.repo.Create(ctx, &stored)
+}
+
+// EmailChannel enqueues an email.send job for the notification, the mail
+// queue renders and sends it with its own retries. Notifications without a
+// template use the generic "notification" template.
+type EmailChannel struct {
+	runtime *worker.Runtime
+	users   user.Repository
+}
+
+// NewEmailChannel creates a new email channel, runtime must have mailer.JobType registered
+func NewEmailChannel(runtime *worker.Runtime, users user.Repository) *EmailChannel {
+	return &EmailChannel{runtime: runtime, users: users}
+}
+
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+func (c *EmailChannel) Deliver(ctx context.Context, n *Notification) error {
+	// The recipient's saved locale picks the template language
+	to, locale := n.Email, ""
+	if n.UserID != 0 {
+		u, err := c.users.FindByID(ctx, n.UserID)
+		if err != nil {
+			return err
+		}
+		if u == nil && to == "" {
+			// The account is gone, nobody to notify
+			return nil
+		}
+		if u != nil {
+			locale = u.Locale
+			if to == "" {
+				to = u.Email
+			}
+		}
+	}
+
+	template := n.Template
+	if template == "" {
+		template = "notification"
+	}
+
+	data := map[string]interface{}{
+		"Title": n.Title,
+		"Body":  n.Body,
+	}
+	if len(n.Data) > 0 {
+		var extra map[string]interface{}
+		if err := json.Unmarshal(n.Data, &extra); err == nil {
+			for key, value := range extra {
+				data[key] = value
+			}
+		}
+	}
+
+	email := mailer.Email{To: to, Template: template, Locale: locale, Data: data}
+	// A retried delivery must not send the email twice
+	_, err := c.runtime.Enqueue(ctx, mailer.JobType, email,
+		worker.WithUniqueKey(fmt.Sprintf("email:%s:%d", n.Key, n.UserID)))
+	if errors.Is(err, worker.ErrDuplicateJob) {
+		return nil
+	}
+	return err
+}
+
+// WebhookChannel posts notifications as JSON to an integration endpoint
+type WebhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookChannel creates a new webhook channel
+func NewWebhookChannel(url string, timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (c *WebhookChannel) Name() string { return ChannelWebhook }
+
+func (c *WebhookChannel) Deliver(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"user_id":    n.UserID,
+		"key":        n.Key,
+		"category":   n.Category,
+		"title":      n.Title,
+		"body":       n.Body,
+		"data":       n.Data,
+		"created_at": n.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification webhook failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PushChannel is a stand-in until a push provider is integrated, it only logs
+type PushChannel struct {
+	logger *logger.Logger
+}
+
+// NewPushChannel creates a new push channel stub
+func NewPushChannel(logger *logger.Logger) *PushChannel {
+	return &PushChannel{logger: logger}
+}
+
+func (c *PushChannel) Name() string { return ChannelPush }
+
+func (c *PushChannel) Deliver(ctx context.Context, n *Notification) error {
+	c.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":  n.UserID,
+		"category": n.Category,
+		"title":    n.Title,
+	}).Debug("Push notification skipped, no push provider configured")
+	return nil
+}
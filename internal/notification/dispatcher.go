@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/events"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/worker"
+)
+
+// DeliverJobType is the worker job delivering a notification on one channel
+const DeliverJobType = "notification.deliver"
+
+// Delivery is the payload of a notification.deliver job
+type Delivery struct {
+	Channel   string          `json:"channel"`
+	UserID    uint            `json:"user_id"`
+	Key       string          `json:"key"`
+	Category  string          `json:"category"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Email     string          `json:"email,omitempty"`
+	Template  string          `json:"template,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (d *Delivery) notification() *Notification {
+	return &Notification{
+		UserID:    d.UserID,
+		Key:       d.Key,
+		Category:  d.Category,
+		Title:     d.Title,
+		Body:      d.Body,
+		Data:      d.Data,
+		Email:     d.Email,
+		Template:  d.Template,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+// Dispatcher fans a notification out to one job per channel the user opted into
+type Dispatcher struct {
+	repo     Repository
+	runtime  *worker.Runtime
+	channels map[string]Channel
+	logger   *logger.Logger
+}
+
+// NewDispatcher creates a new dispatcher delivering through channels
+func NewDispatcher(repo Repository, runtime *worker.Runtime, logger *logger.Logger, channels ...Channel) *Dispatcher {
+	d := &Dispatcher{
+		repo:     repo,
+		runtime:  runtime,
+		channels: make(map[string]Channel, len(channels)),
+		logger:   logger,
+	}
+	for _, channel := range channels {
+		d.channels[channel.Name()] = channel
+	}
+	return d
+}
+
+// Register binds the delivery job to queue
+func (d *Dispatcher) Register(queue string, maxRetries int) {
+	d.runtime.Register(DeliverJobType, queue, maxRetries, d.deliver)
+}
+
+// HandleEvent is an events.Handler turning user events into notifications
+func (d *Dispatcher) HandleEvent(ctx context.Context, msg *events.Message) error {
+	var event domain.Event
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	n, err := FromEvent(event)
+	if err != nil || n == nil {
+		return err
+	}
+	return d.Dispatch(ctx, n)
+}
+
+// Dispatch enqueues a delivery for every configured channel the user has enabled.
+// n.Key makes the fan-out idempotent when an event is redelivered.
+func (d *Dispatcher) Dispatch(ctx context.Context, n *Notification) error {
+	stored, err := d.repo.Preferences(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	prefs := resolvePreferences(stored)
+
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+	for _, name := range Channels {
+		if _, ok := d.channels[name]; !ok || !prefs.Enabled(n.Category, name) {
+			continue
+		}
+
+		delivery := Delivery{
+			Channel:   name,
+			UserID:    n.UserID,
+			Key:       n.Key,
+			Category:  n.Category,
+			Title:     n.Title,
+			Body:      n.Body,
+			Data:      n.Data,
+			Email:     n.Email,
+			Template:  n.Template,
+			CreatedAt: n.CreatedAt,
+		}
+		_, err := d.runtime.Enqueue(ctx, DeliverJobType, delivery,
+			worker.WithUniqueKey(fmt.Sprintf("notification:%s:%d:%s", n.Key, n.UserID, name)))
+		if err != nil && !errors.Is(err, worker.ErrDuplicateJob) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver runs one notification.deliver job
+func (d *Dispatcher) deliver(ctx context.Context, job *worker.Job) error {
+	var delivery Delivery
+	if err := job.Decode(&delivery); err != nil {
+		return worker.Permanent(err)
+	}
+
+	channel, ok := d.channels[delivery.Channel]
+	if !ok {
+		return worker.Permanent(fmt.Errorf("unknown notification channel %q", delivery.Channel))
+	}
+
+	return channel.Deliver(ctx, delivery.notification())
+}
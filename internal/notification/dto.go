@@ -0,0 +1,27 @@
+package notification
+
+type ListNotificationsRequest struct {
+	Unread  bool `form:"unread"`
+	Page    int  `form:"page"`
+	PerPage int  `form:"per_page"`
+}
+
+type PreferenceRequest struct {
+	Category string `json:"category" binding:"required"`
+	Channel  string `json:"channel" binding:"required"`
+	Enabled  *bool  `json:"enabled" binding:"required"`
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []PreferenceRequest `json:"preferences" binding:"required,dive"`
+}
+
+type PreferencesResponse struct {
+	Categories []string    `json:"categories"`
+	Channels   []string    `json:"channels"`
+	Matrix     Preferences `json:"preferences"`
+}
+
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"go-user-service/internal/domain"
+)
+
+// FromEvent builds the notification for a user event, or nil when the
+// event does not notify the user
+func FromEvent(event domain.Event) (*Notification, error) {
+	userID, err := strconv.ParseUint(event.AggregateID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notification{
+		UserID:    uint(userID),
+		Key:       event.ID,
+		Category:  CategoryAccount,
+		CreatedAt: event.OccurredAt,
+	}
+
+	switch event.Type {
+	case domain.UserRegistered:
+		var payload domain.UserRegisteredPayload
+		if err := event.Decode(&payload); err != nil {
+			return nil, err
+		}
+		n.Title = "Welcome aboard"
+		n.Body = "Your account has been created."
+		n.Template = "welcome"
+		n.Email = payload.Email
+		n.Data, _ = json.Marshal(map[string]string{"Username": payload.Username, "Email": payload.Email})
+	case domain.UserEmailVerified:
+		n.Title = "Email address verified"
+		n.Body = "Your email address has been verified."
+	case domain.UserPasswordChanged:
+		n.Category = CategorySecurity
+		n.Title = "Password changed"
+		n.Body = "The password of your account was changed. If this was not you, reset it immediately."
+		n.Template = "password_changed"
+	case domain.UserProfileUpdated:
+		var payload domain.UserProfileUpdatedPayload
+		if err := event.Decode(&payload); err != nil {
+			return nil, err
+		}
+		n.Title = "Profile updated"
+		n.Body = "Your profile has been updated."
+		n.Data, _ = json.Marshal(map[string][]string{"changed_fields": payload.ChangedFields})
+	case domain.UserDeleted:
+		var payload domain.UserDeletedPayload
+		if err := event.Decode(&payload); err != nil {
+			return nil, err
+		}
+		n.Title = "Account deleted"
+		n.Body = "Your account has been deleted."
+		n.Template = "account_deleted"
+		n.Email = payload.Email
+		n.Data, _ = json.Marshal(map[string]string{"Email": payload.Email})
+	default:
+		return nil, nil
+	}
+
+	return n, nil
+}
@@ -0,0 +1,122 @@
+package notification
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisRoutes registers the inbox and preference routes, rg must require authentication
+func (h *Handler) RegisRoutes(rg *gin.RouterGroup) {
+	me := rg.Group("/users/me")
+	me.GET("/notifications", h.List)
+	me.GET("/notifications/unread-count", h.UnreadCount)
+	me.POST("/notifications/read-all", h.MarkAllRead)
+	me.POST("/notifications/:id/read", h.MarkRead)
+	me.POST("/notifications/:id/unread", h.MarkUnread)
+	me.GET("/notification-preferences", h.GetPreferences)
+	me.PUT("/notification-preferences", h.UpdatePreferences)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	var req ListNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid query parameters"))
+		return
+	}
+
+	notifications, meta, appErr := h.service.List(c.Request.Context(), userID, req)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.JSONWithMeta(c, http.StatusOK, notifications, meta)
+}
+
+func (h *Handler) UnreadCount(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	count, appErr := h.service.CountUnread(c.Request.Context(), userID)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, count)
+}
+
+func (h *Handler) MarkRead(c *gin.Context) {
+	h.setRead(c, true)
+}
+
+func (h *Handler) MarkUnread(c *gin.Context) {
+	h.setRead(c, false)
+}
+
+func (h *Handler) setRead(c *gin.Context, read bool) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, errors.New(errors.ErrCodeValidation, "Invalid notification ID format"))
+		return
+	}
+
+	if appErr := h.service.MarkRead(c.Request.Context(), userID, uint(id), read); appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	if appErr := h.service.MarkAllRead(c.Request.Context(), userID); appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) GetPreferences(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	prefs, appErr := h.service.GetPreferences(c.Request.Context(), userID)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, prefs)
+}
+
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid notification preferences"))
+		return
+	}
+
+	prefs, appErr := h.service.UpdatePreferences(c.Request.Context(), userID, req)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, prefs)
+}
@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Categories group notifications for opt-in and opt-out
+const (
+	CategoryAccount   = "account"
+	CategorySecurity  = "security"
+	CategoryMarketing = "marketing"
+)
+
+// Categories lists every known category
+var Categories = []string{CategoryAccount, CategorySecurity, CategoryMarketing}
+
+// Channel names
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelPush    = "push"
+)
+
+// Channels lists every known channel
+var Channels = []string{ChannelInApp, ChannelEmail, ChannelWebhook, ChannelPush}
+
+// defaultEnabled is used for every category and channel a user never changed
+var defaultEnabled = map[string]map[string]bool{
+	CategoryAccount:   {ChannelInApp: true, ChannelEmail: true, ChannelWebhook: false, ChannelPush: true},
+	CategorySecurity:  {ChannelInApp: true, ChannelEmail: true, ChannelWebhook: false, ChannelPush: true},
+	CategoryMarketing: {ChannelInApp: true, ChannelEmail: false, ChannelWebhook: false, ChannelPush: false},
+}
+
+type Notification struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"-"`
+	Key       string          `json:"-"`
+	Category  string          `json:"category"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data,omitempty" gorm:"type:jsonb"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	// Email overrides the recipient, e.g. for accounts that no longer exist
+	Email string `json:"-" gorm:"-"`
+	// Template is the mailer template used by the email channel
+	Template string `json:"-" gorm:"-"`
+}
+
+type Preference struct {
+	UserID    uint      `json:"-" gorm:"primaryKey"`
+	Category  string    `json:"category" gorm:"primaryKey"`
+	Channel   string    `json:"channel" gorm:"primaryKey"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName implements gorm.Tabler
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
+
+// Preferences is the effective opt-in matrix of a user
+type Preferences map[string]map[string]bool
+
+// Enabled reports whether the user receives category notifications on channel
+func (p Preferences) Enabled(category, channel string) bool {
+	return p[category][channel]
+}
+
+// resolvePreferences overlays the stored choices on the defaults
+func resolvePreferences(stored []Preference) Preferences {
+	prefs := make(Preferences, len(defaultEnabled))
+	for category, channels := range defaultEnabled {
+		prefs[category] = make(map[string]bool, len(channels))
+		for channel, enabled := range channels {
+			prefs[category][channel] = enabled
+		}
+	}
+	for _, p := range stored {
+		if _, ok := prefs[p.Category]; ok {
+			prefs[p.Category][p.Channel] = p.Enabled
+		}
+	}
+	return prefs
+}
@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Create stores n unless the user already has a notification with the same key
+	Create(ctx context.Context, n *Notification) error
+	List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	// SetRead returns false when the notification does not belong to the user
+	SetRead(ctx context.Context, userID, id uint, read bool) (bool, error)
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+	Preferences(ctx context.Context, userID uint) ([]Preference, error)
+	SavePreferences(ctx context.Context, prefs []Preference) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, n *Notification) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "key"}}, DoNothing: true}).
+		Create(n).Error
+}
+
+func (r *repository) List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]Notification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []Notification
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *repository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repository) SetRead(ctx context.Context, userID, id uint, read bool) (bool, error) {
+	var readAt interface{}
+	if read {
+		readAt = gorm.Expr("COALESCE(read_at, ?)", time.Now())
+	}
+
+	result := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", readAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *repository) Preferences(ctx context.Context, userID uint) ([]Preference, error) {
+	var prefs []Preference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *repository) SavePreferences(ctx context.Context, prefs []Preference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+		}).
+		Create(&prefs).Error
+}
@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/pkg/tracing"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+type Service interface {
+	List(ctx context.Context, userID uint, req ListNotificationsRequest) ([]Notification, *response.Meta, *errors.AppError)
+	CountUnread(ctx context.Context, userID uint) (*UnreadCountResponse, *errors.AppError)
+	MarkRead(ctx context.Context, userID, id uint, read bool) *errors.AppError
+	MarkAllRead(ctx context.Context, userID uint) *errors.AppError
+	GetPreferences(ctx context.Context, userID uint) (*PreferencesResponse, *errors.AppError)
+	UpdatePreferences(ctx context.Context, userID uint, req UpdatePreferencesRequest) (*PreferencesResponse, *errors.AppError)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) List(ctx context.Context, userID uint, req ListNotificationsRequest) ([]Notification, *response.Meta, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "notification.Service.List")
+	defer span.End()
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PerPage < 1 {
+		req.PerPage = defaultPerPage
+	}
+	if req.PerPage > maxPerPage {
+		req.PerPage = maxPerPage
+	}
+
+	notifications, total, err := s.repo.List(ctx, userID, req.Unread, req.PerPage, (req.Page-1)*req.PerPage)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to list notifications")
+	}
+
+	return notifications, &response.Meta{
+		Page:       req.Page,
+		PerPage:    req.PerPage,
+		Total:      int(total),
+		TotalPages: int((total + int64(req.PerPage) - 1) / int64(req.PerPage)),
+	}, nil
+}
+
+func (s *service) CountUnread(ctx context.Context, userID uint) (*UnreadCountResponse, *errors.AppError) {
+	count, err := s.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to count notifications")
+	}
+	return &UnreadCountResponse{Unread: count}, nil
+}
+
+func (s *service) MarkRead(ctx context.Context, userID, id uint, read bool) *errors.AppError {
+	found, err := s.repo.SetRead(ctx, userID, id, read)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabase, "Failed to update notification")
+	}
+	if !found {
+		return errors.New(errors.ErrCodeNotFound, "Notification not found")
+	}
+	return nil
+}
+
+func (s *service) MarkAllRead(ctx context.Context, userID uint) *errors.AppError {
+	if _, err := s.repo.MarkAllRead(ctx, userID); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabase, "Failed to update notifications")
+	}
+	return nil
+}
+
+func (s *service) GetPreferences(ctx context.Context, userID uint) (*PreferencesResponse, *errors.AppError) {
+	stored, err := s.repo.Preferences(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to load notification preferences")
+	}
+
+	return &PreferencesResponse{
+		Categories: Categories,
+		Channels:   Channels,
+		Matrix:     resolvePreferences(stored),
+	}, nil
+}
+
+func (s *service) UpdatePreferences(ctx context.Context, userID uint, req UpdatePreferencesRequest) (*PreferencesResponse, *errors.AppError) {
+	now := time.Now()
+	prefs := make([]Preference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		if !slices.Contains(Categories, p.Category) {
+			return nil, errors.New(errors.ErrCodeValidation, fmt.Sprintf("Unknown notification category %q", p.Category))
+		}
+		if !slices.Contains(Channels, p.Channel) {
+			return nil, errors.New(errors.ErrCodeValidation, fmt.Sprintf("Unknown notification channel %q", p.Channel))
+		}
+		prefs = append(prefs, Preference{
+			UserID:    userID,
+			Category:  p.Category,
+			Channel:   p.Channel,
+			Enabled:   *p.Enabled,
+			UpdatedAt: now,
+		})
+	}
+
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to save notification preferences")
+	}
+	return s.GetPreferences(ctx, userID)
+}
@@ -0,0 +1,79 @@
+// Package auth issues and verifies the HS256 access tokens of the service
+// and carries the authenticated user through request contexts.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for malformed, expired or badly signed tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// NewToken issues an access token for the user
+func NewToken(secret string, userID uint, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseToken verifies an access token and returns the user ID it was issued for
+func ParseToken(secret, token string) (uint, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%w: bad subject", ErrInvalidToken)
+	}
+	return uint(id), nil
+}
+
+// Keys returns the secrets tokens may be signed with, the current one first.
+// Tokens signed with an older key stay valid after a rotation until they expire.
+type Keys func() []string
+
+// StaticKeys returns Keys for a secret that never changes
+func StaticKeys(secret string) Keys {
+	return func() []string { return []string{secret} }
+}
+
+// Verify checks token against every key and returns the user ID it was issued for
+func Verify(keys Keys, token string) (uint, error) {
+	err := ErrInvalidToken
+	for _, secret := range keys() {
+		var userID uint
+		userID, err = ParseToken(secret, token)
+		if err == nil {
+			return userID, nil
+		}
+	}
+	return 0, err
+}
+
+type userIDKey struct{}
+
+// WithUserID returns a context carrying the authenticated user ID
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID returns the authenticated user ID of ctx
+func UserID(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userIDKey{}).(uint)
+	return id, ok
+}
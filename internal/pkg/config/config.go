@@ -1,122 +1,280 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"strings"
 	"time"
 )
 
-// Config holds all configuration for our application
+// Config holds all configuration for our application.
+//
+// Every value is read from, in increasing precedence, the `default` tag, the
+// config file, the `env` variable and the command-line flag named after `key`,
+// e.g. -database.host. A `default_<env>` tag replaces the default when APP_ENV
+// is that environment, e.g. default_production. Fields without a key tag are
+// not loaded. Fields with a `secret` tag may also come from <env>_FILE or the
+// configured secret provider.
 type Config struct {
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	OAuth    OAuthConfig
-	Server   ServerConfig
-	App      AppConfig
+	Database     DatabaseConfig     `key:"database"`
+	Redis        RedisConfig        `key:"redis"`
+	JWT          JWTConfig          `key:"jwt"`
+	OAuth        OAuthConfig        `key:"oauth"`
+	Server       ServerConfig       `key:"server"`
+	App          AppConfig          `key:"app"`
+	Tracing      TracingConfig      `key:"tracing"`
+	Outbox       OutboxConfig       `key:"outbox"`
+	Worker       WorkerConfig       `key:"worker"`
+	Cron         CronConfig         `key:"cron"`
+	Mail         MailConfig         `key:"mail"`
+	Notification NotificationConfig `key:"notification"`
+	Webhook      WebhookConfig      `key:"webhook"`
+	Secrets      SecretsConfig      `key:"secrets"`
+	Startup      StartupConfig      `key:"startup"`
+	UserCache    UserCacheConfig    `key:"user_cache"`
+
+	// Source records how the configuration was loaded
+	Source Source
+
+	// rotating holds the sources and values of secrets that can change at runtime
+	rotating *rotating
+}
+
+// Source records the options that control loading rather than set values
+type Source struct {
+	// File is the config file that was read, empty when none
+	File string
+	// PrintConfig is set by --print-config
+	PrintConfig bool
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `key:"host" env:"DB_HOST" default:"localhost"`
+	Port     string `key:"port" env:"DB_PORT" default:"5432"`
+	User     string `key:"user" env:"DB_USER" default:"postgres"`
+	Password string `key:"password" env:"DB_PASSWORD" secret:"db_password"`
+	DBName   string `key:"name" env:"DB_NAME" default:"user_service"`
+	SSLMode  string `key:"sslmode" env:"DB_SSLMODE" default:"disable"`
 	DSN      string
+
+	// AllowPendingMigrations lets the API start while the schema is behind
+	AllowPendingMigrations bool `key:"allow_pending_migrations" env:"DB_ALLOW_PENDING_MIGRATIONS" default:"false"`
+
+	// Connection pool of database/sql
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"50" default_development:"10" default_production:"100"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10" default_development:"5" default_production:"25"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"1h"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"10m"`
+	// ConnectTimeout bounds the startup ping
+	ConnectTimeout time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"5s"`
+
+	// Replicas lists read replica DSNs separated by commas, the API sends reads
+	// to them. A DSN without a password uses the primary's, one with a
+	// password is redacted by Print.
+	Replicas string `key:"replicas" env:"DB_REPLICAS" redact:"true"`
+	// ReplicaMaxLag takes a replica out of rotation while its replay is further behind
+	ReplicaMaxLag        time.Duration `key:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"10s"`
+	ReplicaCheckInterval time.Duration `key:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s"`
 }
 
 // RedisConfig holds redis configuration
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	Host     string `key:"host" env:"REDIS_HOST" default:"localhost"`
+	Port     string `key:"port" env:"REDIS_PORT" default:"6379"`
+	Password string `key:"password" env:"REDIS_PASSWORD" secret:"redis_password"`
+	DB       int    `key:"db" env:"REDIS_DB" default:"0"`
 	Address  string
+
+	// Connection pool of go-redis
+	PoolSize        int           `key:"pool_size" env:"REDIS_POOL_SIZE" default:"20" default_development:"10" default_production:"50"`
+	MinIdleConns    int           `key:"min_idle_conns" env:"REDIS_MIN_IDLE_CONNS" default:"5" default_development:"1" default_production:"10"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"REDIS_MAX_IDLE_CONNS" default:"10" default_development:"5" default_production:"25"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"REDIS_CONN_MAX_IDLE_TIME" default:"5m"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"REDIS_CONN_MAX_LIFETIME" default:"1h"`
+	PoolTimeout     time.Duration `key:"pool_timeout" env:"REDIS_POOL_TIMEOUT" default:"4s"`
+	DialTimeout     time.Duration `key:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" default:"5s"`
+	ReadTimeout     time.Duration `key:"read_timeout" env:"REDIS_READ_TIMEOUT" default:"3s"`
+	WriteTimeout    time.Duration `key:"write_timeout" env:"REDIS_WRITE_TIMEOUT" default:"3s"`
 }
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret           string
-	ExpiresIn        time.Duration
-	RefreshExpiresIn time.Duration
+	Secret           string        `key:"secret" env:"JWT_SECRET" default:"your-secret-key" secret:"jwt_secret"`
+	ExpiresIn        time.Duration `key:"expires_in" env:"JWT_EXPIRES_IN" default:"24h"`
+	RefreshExpiresIn time.Duration `key:"refresh_expires_in" env:"JWT_REFRESH_EXPIRES_IN" default:"168h"` // 7 days
 }
 
 // OAuthConfig holds OAuth configuration
 type OAuthConfig struct {
-	Google   OAuthProviderConfig
-	Facebook OAuthProviderConfig
+	Google   OAuthProviderConfig `key:"google" env:"GOOGLE"`
+	Facebook OAuthProviderConfig `key:"facebook" env:"FACEBOOK"`
 }
 
-// OAuthProviderConfig holds OAuth provider configuration
+// OAuthProviderConfig holds OAuth provider configuration. Its env and secret
+// names are prefixed with the env tag of the provider, e.g. GOOGLE_CLIENT_ID.
 type OAuthProviderConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURL  string
+	ClientID     string `key:"client_id" env:"CLIENT_ID"`
+	ClientSecret string `key:"client_secret" env:"CLIENT_SECRET" secret:"client_secret"`
+	RedirectURL  string `key:"redirect_url" env:"REDIRECT_URL"`
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	APIPort    string
-	GRPCPort   string
-	WorkerPort string
+	APIPort            string        `key:"api_port" env:"API_PORT" default:"8080"`
+	GRPCPort           string        `key:"grpc_port" env:"GRPC_PORT" default:"9090"`
+	WorkerPort         string        `key:"worker_port" env:"WORKER_PORT" default:"8081"`
+	HealthCheckTimeout time.Duration `key:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	ShutdownDrainDelay time.Duration `key:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	// ShutdownTimeout bounds draining in-flight requests after the drain delay
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	// HTTP server limits of the API
+	ReadTimeout       time.Duration `key:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"15s"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"15s"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"60s"`
+	MaxHeaderBytes    int           `key:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES" default:"1048576"` // 1 MiB
+	// MaxBodyBytes rejects larger request bodies with 413
+	MaxBodyBytes int64 `key:"max_body_bytes" env:"HTTP_MAX_BODY_BYTES" default:"4194304"` // 4 MiB
+	// AdminAPIKey guards the admin routes, they are disabled while it is empty
+	AdminAPIKey string `key:"admin_api_key" env:"ADMIN_API_KEY" secret:"admin_api_key"`
+	// ErrorFormat is "envelope" or "problem" (RFC 7807), clients may still ask for problem details
+	ErrorFormat string `key:"error_format" env:"ERROR_FORMAT" default:"envelope"`
+	// ProblemTypeBaseURL prefixes the type URI of problem details
+	ProblemTypeBaseURL string `key:"problem_type_base_url" env:"PROBLEM_TYPE_BASE_URL" default:"/api/v1/errors/"`
 }
 
 // AppConfig holds application configuration
 type AppConfig struct {
-	Name     string
-	Version  string
-	AppEnv   string
-	LogLevel string
-}
-
-// Load loads configuration from environment variables
-func Load() *Config {
-	return &Config{
-		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "user_service"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
-		},
-		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
-		},
-		JWT: JWTConfig{
-			Secret:           getEnv("JWT_SECRET", "your-secret-key"),
-			ExpiresIn:        getEnvAsDuration("JWT_EXPIRES_IN", "24h"),
-			RefreshExpiresIn: getEnvAsDuration("JWT_REFRESH_EXPIRES_IN", "168h"), // 7 days
-		},
-		OAuth: OAuthConfig{
-			Google: OAuthProviderConfig{
-				ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
-				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
-				RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", ""),
-			},
-			Facebook: OAuthProviderConfig{
-				ClientID:     getEnv("FACEBOOK_CLIENT_ID", ""),
-				ClientSecret: getEnv("FACEBOOK_CLIENT_SECRET", ""),
-				RedirectURL:  getEnv("FACEBOOK_REDIRECT_URL", ""),
-			},
-		},
-		Server: ServerConfig{
-			APIPort:    getEnv("API_PORT", "8080"),
-			GRPCPort:   getEnv("GRPC_PORT", "9090"),
-			WorkerPort: getEnv("WORKER_PORT", "8081"),
-		},
-		App: AppConfig{
-			Name:     getEnv("APP_NAME", "user-service"),
-			Version:  getEnv("APP_VERSION", "1.0.0"),
-			AppEnv:   getEnv("APP_ENV", "development"),
-			LogLevel: getEnv("LOG_LEVEL", "info"),
-		},
+	Name     string `key:"name" env:"APP_NAME" default:"user-service"`
+	Version  string `key:"version" env:"APP_VERSION" default:"1.0.0"`
+	Commit   string `key:"commit" env:"APP_COMMIT"`
+	AppEnv   string `key:"env" env:"APP_ENV" default:"development"`
+	LogLevel string `key:"log_level" env:"LOG_LEVEL" default:"info"`
+	// DefaultLocale answers requests whose Accept-Language matches no supported locale
+	DefaultLocale string `key:"default_locale" env:"APP_DEFAULT_LOCALE" default:"en"`
+}
+
+// BuildCommit is set at build time with -ldflags "-X go-user-service/internal/pkg/config.BuildCommit=<sha>"
+var BuildCommit = "unknown"
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter    string  `key:"exporter" env:"TRACING_EXPORTER" default:"none"` // otlp, stdout or none
+	Endpoint    string  `key:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `key:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE" default:"true"`
+	SampleRatio float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1.0"`
+}
+
+// OutboxConfig holds outbox relay configuration
+type OutboxConfig struct {
+	PollInterval time.Duration `key:"poll_interval" env:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	BatchSize    int           `key:"batch_size" env:"OUTBOX_BATCH_SIZE" default:"100"`
+}
+
+// WorkerConfig holds background worker configuration
+type WorkerConfig struct {
+	Concurrency     int           `key:"concurrency" env:"WORKER_CONCURRENCY" default:"4"`
+	JobTimeout      time.Duration `key:"job_timeout" env:"WORKER_JOB_TIMEOUT" default:"1m"`
+	PollInterval    time.Duration `key:"poll_interval" env:"WORKER_POLL_INTERVAL" default:"1s"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"WORKER_SHUTDOWN_TIMEOUT" default:"30s"`
+}
+
+// CronConfig holds periodic maintenance task configuration.
+// Schedules are cron expressions, an empty schedule disables the task.
+type CronConfig struct {
+	Timezone string        `key:"timezone" env:"CRON_TIMEZONE" default:"UTC"`
+	LeaseTTL time.Duration `key:"lease_ttl" env:"CRON_LEASE_TTL" default:"30s"`
+	History  int64         `key:"history" env:"CRON_HISTORY" default:"50"`
+
+	PurgeTokensSchedule  string        `key:"purge_tokens" env:"CRON_PURGE_TOKENS" default:"*/15 * * * *"`
+	PurgeUsersSchedule   string        `key:"purge_users" env:"CRON_PURGE_USERS" default:"0 3 * * *"`
+	CleanOutboxSchedule  string        `key:"clean_outbox" env:"CRON_CLEAN_OUTBOX" default:"30 * * * *"`
+	UserStatsSchedule    string        `key:"user_stats" env:"CRON_USER_STATS" default:"*/5 * * * *"`
+	DeletedUserRetention time.Duration `key:"deleted_user_retention" env:"DELETED_USER_RETENTION" default:"720h"` // 30 days
+	OutboxRetention      time.Duration `key:"outbox_retention" env:"OUTBOX_RETENTION" default:"168h"`             // 7 days
+}
+
+// MailConfig holds email delivery configuration
+type MailConfig struct {
+	// Driver is smtp, file (Maildir in Dir) or memory
+	Driver   string `key:"driver" env:"MAIL_DRIVER" default:"smtp"`
+	Host     string `key:"host" env:"MAIL_HOST" default:"localhost"`
+	Port     string `key:"port" env:"MAIL_PORT" default:"1025"`
+	Username string `key:"username" env:"MAIL_USERNAME"`
+	Password string `key:"password" env:"MAIL_PASSWORD" secret:"mail_password"`
+	// TLS is none, starttls or tls
+	TLS           string `key:"tls" env:"MAIL_TLS" default:"none"`
+	From          string `key:"from" env:"MAIL_FROM" default:"User Service <no-reply@localhost>"`
+	Dir           string `key:"dir" env:"MAIL_DIR" default:"tmp/mail"`
+	DefaultLocale string `key:"default_locale" env:"MAIL_DEFAULT_LOCALE" default:"id"`
+	MaxRetries    int    `key:"max_retries" env:"MAIL_MAX_RETRIES" default:"5"`
+}
+
+// NotificationConfig holds notification delivery configuration
+type NotificationConfig struct {
+	// WebhookURL receives notifications on the webhook channel, empty disables the channel
+	WebhookURL     string        `key:"webhook_url" env:"NOTIFICATION_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `key:"webhook_timeout" env:"NOTIFICATION_WEBHOOK_TIMEOUT" default:"10s"`
+	MaxRetries     int           `key:"max_retries" env:"NOTIFICATION_MAX_RETRIES" default:"5"`
+}
+
+// WebhookConfig holds outgoing webhook delivery configuration
+type WebhookConfig struct {
+	Timeout    time.Duration `key:"timeout" env:"WEBHOOK_TIMEOUT" default:"10s"`
+	MaxRetries int           `key:"max_retries" env:"WEBHOOK_MAX_RETRIES" default:"8"`
+	// DisableAfter consecutive failed attempts disables an endpoint
+	DisableAfter    int   `key:"disable_after" env:"WEBHOOK_DISABLE_AFTER" default:"25"`
+	MaxResponseBody int64 `key:"max_response_body" env:"WEBHOOK_MAX_RESPONSE_BODY" default:"65536"`
+	Concurrency     int   `key:"concurrency" env:"WEBHOOK_CONCURRENCY" default:"4"`
+}
+
+// SecretsConfig selects where secrets missing from the environment are read from
+type SecretsConfig struct {
+	// Provider is none, file (one file per secret in Dir) or sealed (SealedPath encrypted with SealedKey)
+	Provider   string `key:"provider" env:"SECRETS_PROVIDER" default:"none"`
+	Dir        string `key:"dir" env:"SECRETS_DIR" default:"/run/secrets"`
+	SealedPath string `key:"sealed_path" env:"SECRETS_SEALED_PATH"`
+	// SealedKey is the base64 key of SealedPath, usually given as SECRETS_SEALED_KEY_FILE
+	SealedKey string `key:"sealed_key" env:"SECRETS_SEALED_KEY"`
+	// RefreshInterval is how often rotating secrets are read again
+	RefreshInterval time.Duration `key:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL" default:"1m"`
+}
+
+// StartupConfig controls how long the services wait for Postgres and Redis to come up
+type StartupConfig struct {
+	// InitialBackoff is the delay after the first failed attempt, doubled after every
+	// further one up to MaxBackoff
+	InitialBackoff time.Duration `key:"initial_backoff" env:"STARTUP_INITIAL_BACKOFF" default:"500ms"`
+	MaxBackoff     time.Duration `key:"max_backoff" env:"STARTUP_MAX_BACKOFF" default:"10s"`
+	// Deadline bounds the total wait, the process exits when a dependency is still down
+	Deadline time.Duration `key:"deadline" env:"STARTUP_DEADLINE" default:"1m"`
+	// Degraded starts the API before its dependencies are up, serving liveness
+	// but failing readiness until they are
+	Degraded bool `key:"degraded" env:"STARTUP_DEGRADED" default:"false"`
+}
+
+// UserCacheConfig controls the Redis cache in front of user lookups
+type UserCacheConfig struct {
+	Enabled bool          `key:"enabled" env:"USER_CACHE_ENABLED" default:"true"`
+	TTL     time.Duration `key:"ttl" env:"USER_CACHE_TTL" default:"5m"`
+	// NegativeTTL is how long a lookup that found no user is remembered
+	NegativeTTL time.Duration `key:"negative_ttl" env:"USER_CACHE_NEGATIVE_TTL" default:"30s"`
+	// LocalTTL keeps users in process memory as well, 0 disables the in-process cache
+	LocalTTL  time.Duration `key:"local_ttl" env:"USER_CACHE_LOCAL_TTL" default:"10s"`
+	LocalSize int           `key:"local_size" env:"USER_CACHE_LOCAL_SIZE" default:"10000"`
+	// LockTTL bounds how long other replicas wait while one loads a missing user
+	LockTTL time.Duration `key:"lock_ttl" env:"USER_CACHE_LOCK_TTL" default:"2s"`
+}
+
+// ReplicaDSNs splits Replicas into one DSN per replica
+func (d *DatabaseConfig) ReplicaDSNs() []string {
+	var dsns []string
+	for _, dsn := range strings.Split(d.Replicas, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
 	}
+	return dsns
 }
 
 // BuildDSN builds database DSN from config
@@ -145,40 +303,14 @@ func (a *AppConfig) IsProduction() bool {
 	return a.AppEnv == "production" || a.AppEnv == "prod"
 }
 
-// Helper functions
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// environment returns AppEnv with its aliases resolved, e.g. "production" for "prod"
+func (a *AppConfig) environment() string {
+	switch {
+	case a.IsProduction():
+		return "production"
+	case a.IsDevelopment():
+		return "development"
+	default:
+		return strings.ToLower(a.AppEnv)
 	}
-	return defaultValue
 }
-
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
-
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
-	}
-	return defaultValue
-}
-
-func getEnvAsDuration(key string, defaultValue string) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
-	}
-	if duration, err := time.ParseDuration(defaultValue); err == nil {
-		return duration
-	}
-	return 24 * time.Hour // fallback
-}
\ No newline at end of file
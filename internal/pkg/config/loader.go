@@ -0,0 +1,332 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-user-service/internal/pkg/secrets"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when -config is not given
+const FileEnv = "CONFIG_FILE"
+
+// field is one configurable value of Config
+type field struct {
+	// key is the dotted path used by the config file and flags, e.g. database.host
+	key string
+	env string
+	def string
+	// secret names the value in secret providers, empty for plain settings
+	secret string
+	tag    reflect.StructTag
+	value  reflect.Value
+}
+
+// layer is a configuration source, later layers take precedence
+type layer int
+
+const (
+	layerDefault layer = iota
+	layerFile
+	layerProvider
+	layerEnv
+	layerFlag
+)
+
+// Load builds the configuration from defaults, the config file (-config or
+// CONFIG_FILE, YAML or TOML), the secret provider, environment variables and
+// args, in increasing precedence, then validates it. The error lists every problem found. The
+// returned Config is nil only when args cannot be parsed, so --print-config
+// still works for an invalid configuration.
+func Load(args []string) (*Config, error) {
+	return load(args, (*Config).Validate)
+}
+
+// LoadDatabase loads the configuration like Load, but only validates what
+// connecting to the database needs, see Config.ValidateDatabase
+func LoadDatabase(args []string) (*Config, error) {
+	return load(args, (*Config).ValidateDatabase)
+}
+
+func load(args []string, validate func(*Config) error) (*Config, error) {
+	cfg := &Config{App: AppConfig{Commit: BuildCommit}, rotating: newRotating()}
+	fields := cfg.fields()
+
+	flagValues, err := parseFlags(fields, &cfg.Source, args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Source.File == "" {
+		cfg.Source.File = os.Getenv(FileEnv)
+	}
+
+	var problems []error
+	var fileValues map[string]string
+	if cfg.Source.File != "" {
+		fileValues, err = readFile(cfg.Source.File)
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	known := make(map[string]bool, len(fields))
+	layers := make(map[string]layer, len(fields))
+	for _, f := range fields {
+		known[f.key] = true
+		from, source, err := f.load(fileValues, flagValues)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		layers[f.key] = from
+		if source != nil {
+			cfg.rotating.sources[f.key] = source
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, cfg.loadEnvironmentDefaults(fields, layers)...)
+	}
+	if len(problems) == 0 {
+		problems = append(problems, cfg.loadSecrets(fields, layers)...)
+	}
+	for _, key := range sortedKeys(fileValues) {
+		if !known[key] {
+			problems = append(problems, fmt.Errorf("%s: unknown key %s", cfg.Source.File, key))
+		}
+	}
+
+	if len(problems) == 0 {
+		if err := validate(cfg); err != nil {
+			return cfg, err
+		}
+		return cfg, nil
+	}
+	return cfg, fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+}
+
+// load sets the value of f from the highest precedence source that has one.
+// Values read from an <env>_FILE also return the source to re-read on rotation.
+func (f field) load(fileValues, flagValues map[string]string) (layer, secrets.Source, error) {
+	raw, from, origin := f.def, layerDefault, "default"
+	var source secrets.Source
+	if value, ok := fileValues[f.key]; ok {
+		raw, from, origin = value, layerFile, "config file key "+f.key
+	}
+	if f.env != "" {
+		value, path := os.Getenv(f.env), os.Getenv(f.env+"_FILE")
+		switch {
+		case value != "" && path != "":
+			return from, nil, fmt.Errorf("set either %s or %s_FILE, not both", f.env, f.env)
+		case path != "":
+			secret, err := secrets.ReadFile(path)
+			if err != nil {
+				return from, nil, fmt.Errorf("%s_FILE: %w", f.env, err)
+			}
+			raw, from, origin = secret, layerEnv, "file "+path
+			source = secrets.FromFile(path)
+		case value != "":
+			raw, from, origin = value, layerEnv, "environment variable "+f.env
+		}
+	}
+	if value, ok := flagValues[f.key]; ok {
+		raw, from, origin, source = value, layerFlag, "flag -"+f.key, nil
+	}
+	if from == layerDefault && raw == "" {
+		return from, nil, nil
+	}
+
+	if err := setValue(f.value, raw); err != nil {
+		return from, nil, fmt.Errorf("%s: invalid value %q: %w", origin, raw, err)
+	}
+	return from, source, nil
+}
+
+// loadEnvironmentDefaults applies the default_<env> tags of the fields that
+// kept their default
+func (c *Config) loadEnvironmentDefaults(fields []field, layers map[string]layer) []error {
+	var problems []error
+	name := "default_" + c.App.environment()
+	for _, f := range fields {
+		raw, ok := f.tag.Lookup(name)
+		if !ok || layers[f.key] != layerDefault {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, fmt.Errorf("%s tag of %s: invalid value %q: %w", name, f.key, raw, err))
+		}
+	}
+	return problems
+}
+
+// fields lists every value of cfg that has a key tag
+func (c *Config) fields() []field {
+	return collectFields(reflect.ValueOf(c).Elem(), "", "")
+}
+
+func collectFields(v reflect.Value, keyPrefix, envPrefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+		env, secret := sf.Tag.Get("env"), sf.Tag.Get("secret")
+		if env != "" && envPrefix != "" {
+			env = envPrefix + "_" + env
+		}
+		if secret != "" && envPrefix != "" {
+			secret = strings.ToLower(envPrefix) + "_" + secret
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Duration(0)) {
+			fields = append(fields, collectFields(fv, keyPrefix+key+".", env)...)
+			continue
+		}
+		fields = append(fields, field{
+			key:    keyPrefix + key,
+			env:    env,
+			def:    sf.Tag.Get("default"),
+			secret: secret,
+			tag:    sf.Tag,
+			value:  fv,
+		})
+	}
+	return fields
+}
+
+// setValue parses raw into v according to its type
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseFlags registers a flag per field plus -config and -print-config,
+// and returns the raw value of every field flag that was set
+func parseFlags(fields []field, source *Source, args []string) (map[string]string, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	fs.StringVar(&source.File, "config", "", "YAML or TOML config file (env "+FileEnv+")")
+	fs.BoolVar(&source.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+
+	values := make(map[string]string)
+	for _, f := range fields {
+		key := f.key
+		usage := fmt.Sprintf("env %s", f.env)
+		if f.def != "" {
+			usage += fmt.Sprintf(", default %q", f.def)
+		}
+		set := func(raw string) error {
+			values[key] = raw
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(key, usage, func(raw string) error { return set(raw) })
+		} else {
+			fs.Func(key, usage, set)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// readFile flattens a YAML or TOML file into dotted keys
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file type %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", tree); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, tree map[string]interface{}) error {
+	for key, value := range tree {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(values, prefix+key+".", value); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("key %s: lists are not supported", prefix+key)
+		case nil:
+			values[prefix+key] = ""
+		default:
+			values[prefix+key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
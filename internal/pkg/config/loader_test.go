@@ -0,0 +1,302 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// value returns the effective value of the field key as Print formats it
+func value(t *testing.T, cfg *Config, key string) string {
+	t.Helper()
+	for _, f := range cfg.fields() {
+		if f.key == key {
+			return formatValue(f)
+		}
+	}
+	t.Fatalf("no field %s", key)
+	return ""
+}
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		provider map[string]string
+		env      map[string]string
+		args     []string
+		want     map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"database.host": "localhost", "database.password": "", "database.max_open_conns": "10"},
+		},
+		{
+			name: "file over defaults",
+			file: "database:\n  host: file-host\n  password: file-pass\n  max_open_conns: 20\n",
+			want: map[string]string{"database.host": "file-host", "database.password": "file-pass", "database.max_open_conns": "20"},
+		},
+		{
+			name:     "provider over file",
+			file:     "database:\n  host: file-host\n  password: file-pass\n",
+			provider: map[string]string{"db_password": "provider-pass"},
+			want:     map[string]string{"database.host": "file-host", "database.password": "provider-pass"},
+		},
+		{
+			name:     "provider keeps the file value of secrets it does not hold",
+			file:     "database:\n  password: file-pass\n",
+			provider: map[string]string{"redis_password": "provider-pass"},
+			want:     map[string]string{"database.password": "file-pass", "redis.password": "provider-pass"},
+		},
+		{
+			name:     "environment over provider",
+			file:     "database:\n  host: file-host\n",
+			provider: map[string]string{"db_password": "provider-pass"},
+			env:      map[string]string{"DB_HOST": "env-host", "DB_PASSWORD": "env-pass", "DB_MAX_OPEN_CONNS": "30"},
+			want:     map[string]string{"database.host": "env-host", "database.password": "env-pass", "database.max_open_conns": "30"},
+		},
+		{
+			name:     "environment file over provider",
+			provider: map[string]string{"db_password": "provider-pass"},
+			env:      map[string]string{"DB_PASSWORD_FILE": "db_password_file"},
+			want:     map[string]string{"database.password": "env-file-pass"},
+		},
+		{
+			name:     "flags over everything",
+			file:     "database:\n  host: file-host\n  password: file-pass\n",
+			provider: map[string]string{"db_password": "provider-pass"},
+			env:      map[string]string{"DB_HOST": "env-host", "DB_PASSWORD": "env-pass"},
+			args:     []string{"-database.host=flag-host", "-database.password", "flag-pass", "-database.max_open_conns=40"},
+			want:     map[string]string{"database.host": "flag-host", "database.password": "flag-pass", "database.max_open_conns": "40"},
+		},
+		{
+			name: "toml file",
+			file: "[database]\nhost = \"toml-host\"\nmax_open_conns = 25\n",
+			want: map[string]string{"database.host": "toml-host", "database.max_open_conns": "25"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("APP_ENV", "development")
+			t.Setenv(FileEnv, "")
+			for _, name := range []string{"DB_HOST", "DB_PASSWORD", "DB_MAX_OPEN_CONNS", "REDIS_PASSWORD", "SECRETS_PROVIDER"} {
+				t.Setenv(name, "")
+			}
+			writeFile(t, dir, "db_password_file", "env-file-pass\n")
+
+			if tt.file != "" {
+				name := "config.yaml"
+				if strings.HasPrefix(tt.file, "[") {
+					name = "config.toml"
+				}
+				t.Setenv(FileEnv, writeFile(t, dir, name, tt.file))
+			}
+			if tt.provider != nil {
+				secretsDir := filepath.Join(dir, "secrets")
+				if err := os.Mkdir(secretsDir, 0o700); err != nil {
+					t.Fatal(err)
+				}
+				for name, secret := range tt.provider {
+					writeFile(t, secretsDir, name, secret)
+				}
+				t.Setenv("SECRETS_PROVIDER", SecretsProviderFile)
+				t.Setenv("SECRETS_DIR", secretsDir)
+			}
+			for name, v := range tt.env {
+				if strings.HasSuffix(name, "_FILE") {
+					v = filepath.Join(dir, v)
+				}
+				t.Setenv(name, v)
+			}
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got := value(t, cfg, key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEnvironmentDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		appEnv string
+		env    map[string]string
+		file   string
+		want   map[string]string
+	}{
+		{
+			name:   "development",
+			appEnv: "development",
+			want:   map[string]string{"database.max_open_conns": "10", "redis.pool_size": "10", "redis.min_idle_conns": "1"},
+		},
+		{
+			name:   "development alias",
+			appEnv: "dev",
+			want:   map[string]string{"database.max_open_conns": "10", "redis.pool_size": "10"},
+		},
+		{
+			name:   "production",
+			appEnv: "production",
+			want:   map[string]string{"database.max_open_conns": "100", "redis.pool_size": "50", "redis.min_idle_conns": "10"},
+		},
+		{
+			name:   "production alias",
+			appEnv: "prod",
+			want:   map[string]string{"database.max_open_conns": "100"},
+		},
+		{
+			name:   "environment without its own defaults",
+			appEnv: "staging",
+			want:   map[string]string{"database.max_open_conns": "50", "redis.pool_size": "20"},
+		},
+		{
+			name:   "an explicit value beats the environment default",
+			appEnv: "production",
+			env:    map[string]string{"DB_MAX_OPEN_CONNS": "7", "DB_MAX_IDLE_CONNS": "7"},
+			want:   map[string]string{"database.max_open_conns": "7", "database.max_idle_conns": "7"},
+		},
+		{
+			name:   "a file value equal to the default still counts as set",
+			appEnv: "production",
+			file:   "database:\n  max_open_conns: 50\n",
+			want:   map[string]string{"database.max_open_conns": "50", "database.max_idle_conns": "25"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv(FileEnv, "")
+			// Production refuses the development secrets
+			t.Setenv("JWT_SECRET", strings.Repeat("s", minProductionSecretLength))
+			t.Setenv("DB_PASSWORD", "db-pass")
+			for _, name := range []string{"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "REDIS_POOL_SIZE", "REDIS_MIN_IDLE_CONNS"} {
+				t.Setenv(name, "")
+			}
+			if tt.file != "" {
+				t.Setenv(FileEnv, writeFile(t, t.TempDir(), "config.yaml", tt.file))
+			}
+			for name, v := range tt.env {
+				t.Setenv(name, v)
+			}
+
+			cfg, err := Load(nil)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got := value(t, cfg, key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want []string
+	}{
+		{
+			name: "unparsable values from every source",
+			file: "database:\n  max_open_conns: many\n",
+			env:  map[string]string{"REDIS_DB": "first"},
+			args: []string{"-jwt.expires_in=soon"},
+			want: []string{"config file key database.max_open_conns", "environment variable REDIS_DB", "flag -jwt.expires_in"},
+		},
+		{
+			name: "unknown file keys",
+			file: "database:\n  hots: db\n",
+			want: []string{"unknown key database.hots"},
+		},
+		{
+			name: "a value and its file",
+			env:  map[string]string{"DB_PASSWORD": "a", "DB_PASSWORD_FILE": "/run/secrets/db_password"},
+			want: []string{"set either DB_PASSWORD or DB_PASSWORD_FILE, not both"},
+		},
+		{
+			name: "lists",
+			file: "server:\n  api_port: [8080, 8081]\n",
+			want: []string{"key server.api_port: lists are not supported"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(FileEnv, "")
+			for _, name := range []string{"DB_PASSWORD", "DB_PASSWORD_FILE", "REDIS_DB"} {
+				t.Setenv(name, "")
+			}
+			if tt.file != "" {
+				t.Setenv(FileEnv, writeFile(t, t.TempDir(), "config.yaml", tt.file))
+			}
+			for name, v := range tt.env {
+				t.Setenv(name, v)
+			}
+
+			cfg, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("Load() succeeded, want an error")
+			}
+			if cfg == nil {
+				t.Error("Load() returned no config, --print-config needs one")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	cfg, err := Load([]string{"-h"})
+	if !errors.Is(err, flag.ErrHelp) || cfg != nil {
+		t.Errorf("Load(-h) = %v, %v, want flag.ErrHelp without a config", cfg, err)
+	}
+}
+
+func TestLoadDatabaseValidatesOnlyTheDatabase(t *testing.T) {
+	t.Setenv(FileEnv, "")
+	t.Setenv("API_PORT", "not-a-port")
+	t.Setenv("MAIL_DRIVER", "pigeon")
+
+	if _, err := Load(nil); err == nil {
+		t.Error("Load() accepted an invalid server and mail configuration")
+	}
+	if _, err := LoadDatabase(nil); err != nil {
+		t.Errorf("LoadDatabase() error = %v, want the server and mail sections ignored", err)
+	}
+
+	t.Setenv("DB_PORT", "not-a-port")
+	_, err := LoadDatabase(nil)
+	if err == nil || !strings.Contains(err.Error(), "database.port") {
+		t.Errorf("LoadDatabase() error = %v, want database.port reported", err)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of sensitive fields in Print
+const redacted = "[REDACTED]"
+
+// Print writes the effective configuration as YAML, usable as a config file.
+// Values of fields tagged redact:"true" or that look sensitive to
+// logger.IsSensitiveField are redacted.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range c.fields() {
+		path := strings.Split(f.key, ".")
+		parent := root
+		for _, section := range path[:len(path)-1] {
+			parent = child(parent, section)
+		}
+
+		value := formatValue(f)
+		if value != "" && (f.tag.Get("redact") == "true" || logger.IsSensitiveField(path[len(path)-1])) {
+			value = redacted
+		}
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if f.env != "" {
+			node.LineComment = "env " + f.env
+		}
+		parent.Content = append(parent.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: path[len(path)-1]}, node)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// child returns the mapping node of key under parent, adding it when missing
+func child(parent *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			return parent.Content[i+1]
+		}
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	return node
+}
+
+func formatValue(f field) string {
+	if d, ok := f.value.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(f.value.Interface())
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/secrets"
+)
+
+// Secret providers accepted in SecretsConfig.Provider
+const (
+	SecretsProviderNone   = "none"
+	SecretsProviderFile   = "file"
+	SecretsProviderSealed = "sealed"
+)
+
+// providerTimeout bounds reading a secret from the provider at startup
+const providerTimeout = 10 * time.Second
+
+// rotating tracks the secrets that were read from files or a provider
+type rotating struct {
+	mu      sync.Mutex
+	sources map[string]secrets.Source
+	values  map[string]*secrets.Value
+}
+
+func newRotating() *rotating {
+	return &rotating{
+		sources: make(map[string]secrets.Source),
+		values:  make(map[string]*secrets.Value),
+	}
+}
+
+// provider builds the configured secret provider, nil for none
+func (s SecretsConfig) provider() (secrets.Provider, error) {
+	switch s.Provider {
+	case SecretsProviderFile:
+		return secrets.NewFileProvider(s.Dir), nil
+	case SecretsProviderSealed:
+		key, err := secrets.ParseKey(s.SealedKey)
+		if err != nil {
+			return nil, err
+		}
+		return secrets.NewSealedFileProvider(s.SealedPath, key), nil
+	default:
+		return nil, nil
+	}
+}
+
+// loadSecrets reads the secret fields that were not set by the environment
+// or a flag from the configured provider. Secrets the provider does not hold
+// keep their file or default value.
+func (c *Config) loadSecrets(fields []field, layers map[string]layer) []error {
+	provider, err := c.Secrets.provider()
+	if err != nil {
+		return []error{fmt.Errorf("secrets.provider %s: %w", c.Secrets.Provider, err)}
+	}
+	if provider == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	var problems []error
+	for _, f := range fields {
+		if f.secret == "" || layers[f.key] > layerProvider {
+			continue
+		}
+		value, err := provider.Get(ctx, f.secret)
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("secret %s: %w", f.secret, err))
+			continue
+		}
+		f.value.SetString(value)
+		c.rotating.sources[f.key] = secrets.FromProvider(provider, f.secret)
+	}
+	return problems
+}
+
+// Secret returns the value of the secret field key, e.g. "jwt.secret". Secrets
+// read from an <env>_FILE or a provider are kept current by WatchSecrets, others never change.
+func (c *Config) Secret(key string) *secrets.Value {
+	if c.rotating == nil {
+		c.rotating = newRotating()
+	}
+	r := c.rotating
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if value, ok := r.values[key]; ok {
+		return value
+	}
+	for _, f := range c.fields() {
+		if f.key == key && f.secret != "" {
+			value := secrets.NewValue(key, f.value.String(), r.sources[key])
+			r.values[key] = value
+			return value
+		}
+	}
+	panic(fmt.Sprintf("config: %s is not a secret field", key))
+}
+
+// WatchSecrets refreshes the values returned by Secret every
+// Secrets.RefreshInterval until ctx is done. Values requested after it
+// started are not refreshed, so call it once startup is complete.
+func (c *Config) WatchSecrets(ctx context.Context, l *logger.Logger) {
+	if c.rotating == nil {
+		return
+	}
+	r := c.rotating
+	r.mu.Lock()
+	values := make([]*secrets.Value, 0, len(r.values))
+	for key, value := range r.values {
+		if r.sources[key] != nil {
+			values = append(values, value)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(values) == 0 {
+		return
+	}
+	secrets.Watch(ctx, c.Secrets.RefreshInterval, l, values...)
+}
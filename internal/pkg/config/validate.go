@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// insecureSecrets are the JWT secrets shipped as defaults and examples
+var insecureSecrets = []string{"your-secret-key", "your-super-secret-jwt-key-here"}
+
+// minProductionSecretLength is the shortest JWT secret accepted in production
+const minProductionSecretLength = 32
+
+// Validate checks the configuration and returns every problem found.
+// Production also refuses the insecure defaults meant for local development.
+func (c *Config) Validate() error {
+	var v validation
+
+	c.validateDatabase(&v)
+	v.port("redis.port", c.Redis.Port)
+	v.port("server.api_port", c.Server.APIPort)
+	v.port("server.grpc_port", c.Server.GRPCPort)
+	v.port("server.worker_port", c.Server.WorkerPort)
+	v.port("mail.port", c.Mail.Port)
+	v.check(c.Redis.DB >= 0, "redis.db must not be negative")
+
+	v.check(c.Redis.PoolSize > 0, "redis.pool_size must be positive")
+	v.check(c.Redis.MinIdleConns >= 0 && c.Redis.MinIdleConns <= c.Redis.MaxIdleConns,
+		"redis.min_idle_conns must be between 0 and redis.max_idle_conns")
+	v.check(c.Redis.MaxIdleConns <= c.Redis.PoolSize, "redis.max_idle_conns must not exceed redis.pool_size")
+	v.positive("redis.conn_max_idle_time", c.Redis.ConnMaxIdleTime)
+	v.positive("redis.conn_max_lifetime", c.Redis.ConnMaxLifetime)
+	v.positive("redis.pool_timeout", c.Redis.PoolTimeout)
+	v.positive("redis.dial_timeout", c.Redis.DialTimeout)
+	v.positive("redis.read_timeout", c.Redis.ReadTimeout)
+	v.positive("redis.write_timeout", c.Redis.WriteTimeout)
+
+	v.check(c.JWT.Secret != "", "jwt.secret is required")
+	v.positive("jwt.expires_in", c.JWT.ExpiresIn)
+	v.positive("jwt.refresh_expires_in", c.JWT.RefreshExpiresIn)
+
+	v.positive("server.health_check_timeout", c.Server.HealthCheckTimeout)
+	v.check(c.Server.ShutdownDrainDelay >= 0, "server.shutdown_drain_delay must not be negative")
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.positive("server.read_timeout", c.Server.ReadTimeout)
+	v.positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	v.check(c.Server.ReadHeaderTimeout <= c.Server.ReadTimeout, "server.read_header_timeout must not exceed server.read_timeout")
+	v.positive("server.write_timeout", c.Server.WriteTimeout)
+	v.positive("server.idle_timeout", c.Server.IdleTimeout)
+	v.check(c.Server.MaxHeaderBytes >= 4096, "server.max_header_bytes must be at least 4096")
+	v.check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive")
+	v.oneOf("server.error_format", c.Server.ErrorFormat, "envelope", "problem")
+
+	v.check(c.App.Name != "", "app.name is required")
+	v.oneOf("app.log_level", c.App.LogLevel, "panic", "fatal", "error", "warn", "warning", "info", "debug", "trace")
+	v.check(c.App.DefaultLocale != "", "app.default_locale is required")
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "otlp", "stdout", "none")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	v.positive("outbox.poll_interval", c.Outbox.PollInterval)
+	v.check(c.Outbox.BatchSize > 0, "outbox.batch_size must be positive")
+
+	v.check(c.Worker.Concurrency > 0, "worker.concurrency must be positive")
+	v.positive("worker.job_timeout", c.Worker.JobTimeout)
+	v.positive("worker.poll_interval", c.Worker.PollInterval)
+	v.positive("worker.shutdown_timeout", c.Worker.ShutdownTimeout)
+
+	if _, err := time.LoadLocation(c.Cron.Timezone); err != nil {
+		v.add("cron.timezone: %v", err)
+	}
+	v.positive("cron.lease_ttl", c.Cron.LeaseTTL)
+	v.check(c.Cron.History > 0, "cron.history must be positive")
+
+	v.oneOf("mail.driver", c.Mail.Driver, "smtp", "file", "memory")
+	v.oneOf("mail.tls", c.Mail.TLS, "none", "starttls", "tls")
+	v.check(c.Mail.From != "", "mail.from is required")
+	v.check(c.Mail.MaxRetries >= 0, "mail.max_retries must not be negative")
+
+	v.positive("notification.webhook_timeout", c.Notification.WebhookTimeout)
+	v.positive("webhook.timeout", c.Webhook.Timeout)
+	v.check(c.Webhook.Concurrency > 0, "webhook.concurrency must be positive")
+	v.check(c.Webhook.MaxResponseBody > 0, "webhook.max_response_body must be positive")
+
+	v.oneOf("secrets.provider", c.Secrets.Provider, SecretsProviderNone, SecretsProviderFile, SecretsProviderSealed)
+	if c.Secrets.Provider == SecretsProviderSealed {
+		v.check(c.Secrets.SealedPath != "", "secrets.sealed_path is required by the sealed provider")
+	}
+	v.positive("secrets.refresh_interval", c.Secrets.RefreshInterval)
+
+	c.validateStartup(&v)
+
+	if c.UserCache.Enabled {
+		v.positive("user_cache.ttl", c.UserCache.TTL)
+		v.positive("user_cache.negative_ttl", c.UserCache.NegativeTTL)
+		v.check(c.UserCache.LocalTTL >= 0, "user_cache.local_ttl must not be negative")
+		v.check(c.UserCache.LocalTTL == 0 || c.UserCache.LocalSize > 0, "user_cache.local_size must be positive when user_cache.local_ttl is set")
+		v.positive("user_cache.lock_ttl", c.UserCache.LockTTL)
+	}
+
+	if c.App.IsProduction() {
+		v.check(!slices.Contains(insecureSecrets, c.JWT.Secret), "jwt.secret must be changed from the example value in production")
+		v.check(len(c.JWT.Secret) >= minProductionSecretLength,
+			"jwt.secret must be at least %d characters in production", minProductionSecretLength)
+		v.check(c.Mail.Driver != "memory", "mail.driver memory loses every email, it is not allowed in production")
+	}
+
+	return v.err()
+}
+
+// ValidateDatabase checks only what connecting to the database needs, for
+// tools such as migrate that do not serve requests
+func (c *Config) ValidateDatabase() error {
+	var v validation
+	c.validateDatabase(&v)
+	c.validateStartup(&v)
+	return v.err()
+}
+
+func (c *Config) validateDatabase(v *validation) {
+	v.port("database.port", c.Database.Port)
+	v.check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	v.check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must be between 0 and database.max_open_conns")
+	v.positive("database.conn_max_lifetime", c.Database.ConnMaxLifetime)
+	v.positive("database.conn_max_idle_time", c.Database.ConnMaxIdleTime)
+	v.positive("database.connect_timeout", c.Database.ConnectTimeout)
+	v.positive("database.replica_max_lag", c.Database.ReplicaMaxLag)
+	v.positive("database.replica_check_interval", c.Database.ReplicaCheckInterval)
+	if c.App.IsProduction() {
+		v.check(c.Database.Password != "", "database.password is required in production")
+	}
+}
+
+func (c *Config) validateStartup(v *validation) {
+	v.positive("startup.initial_backoff", c.Startup.InitialBackoff)
+	v.check(c.Startup.MaxBackoff >= c.Startup.InitialBackoff, "startup.max_backoff must not be less than startup.initial_backoff")
+	v.positive("startup.deadline", c.Startup.Deadline)
+}
+
+// validation collects problems instead of stopping at the first one
+type validation struct {
+	problems []error
+}
+
+// err joins the problems found, nil when there are none
+func (v *validation) err() error {
+	if len(v.problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(v.problems...))
+	}
+	return nil
+}
+
+func (v *validation) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Errorf(format, args...))
+}
+
+func (v *validation) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.add(format, args...)
+	}
+}
+
+func (v *validation) port(key, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port <= 65535, "%s must be a port number, got %q", key, value)
+}
+
+func (v *validation) positive(key string, d time.Duration) {
+	v.check(d > 0, "%s must be positive", key)
+}
+
+func (v *validation) oneOf(key, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), "%s must be one of %v, got %q", key, allowed, value)
+}
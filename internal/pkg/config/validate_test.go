@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig loads the development defaults, which pass Validate
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	t.Setenv("APP_ENV", "development")
+	t.Setenv(FileEnv, "")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "defaults",
+			mutate: func(c *Config) {},
+		},
+		{
+			name: "collects every problem",
+			mutate: func(c *Config) {
+				c.Database.Port = "0"
+				c.Redis.PoolSize = 0
+				c.Server.ReadHeaderTimeout = c.Server.ReadTimeout + 1
+				c.Mail.Driver = "pigeon"
+				c.Cron.Timezone = "Mars/Olympus_Mons"
+			},
+			want: []string{
+				`database.port must be a port number, got "0"`,
+				"redis.pool_size must be positive",
+				"redis.max_idle_conns must not exceed redis.pool_size",
+				"server.read_header_timeout must not exceed server.read_timeout",
+				"mail.driver must be one of [smtp file memory]",
+				"cron.timezone:",
+			},
+		},
+		{
+			name:   "connection pool bounds",
+			mutate: func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 },
+			want:   []string{"database.max_idle_conns must be between 0 and database.max_open_conns"},
+		},
+		{
+			name:   "sealed provider without a path",
+			mutate: func(c *Config) { c.Secrets.Provider = SecretsProviderSealed },
+			want:   []string{"secrets.sealed_path is required by the sealed provider"},
+		},
+		{
+			name: "user cache only when enabled",
+			mutate: func(c *Config) {
+				c.UserCache.Enabled = false
+				c.UserCache.TTL = 0
+			},
+		},
+		{
+			name: "user cache",
+			mutate: func(c *Config) {
+				c.UserCache.Enabled = true
+				c.UserCache.TTL = 0
+			},
+			want: []string{"user_cache.ttl must be positive"},
+		},
+		{
+			name: "production refuses development settings",
+			mutate: func(c *Config) {
+				c.App.AppEnv = "prod"
+				c.Database.Password = ""
+				c.Mail.Driver = "memory"
+			},
+			want: []string{
+				"jwt.secret must be changed from the example value in production",
+				"jwt.secret must be at least 32 characters in production",
+				"database.password is required in production",
+				"mail.driver memory loses every email",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() succeeded, want an error")
+			}
+			// One problem per line after the header
+			if got := strings.Count(err.Error(), "\n"); got != len(tt.want) {
+				t.Errorf("Validate() reported %d problem(s), want %d:\n%v", got, len(tt.want), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
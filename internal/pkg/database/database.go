@@ -3,35 +3,63 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/secrets"
+	"go-user-service/internal/pkg/tracing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	redis "github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// NewPostgresConnection creates a new PostgreSQL database connection
-func NewPostgresConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
+// NewPostgresConnection creates a new PostgreSQL connection pool without
+// connecting, use PostgresDependency with WaitFor to check it. When password
+// is set, every new connection uses its current value, so a rotated password
+// applies without a restart.
+func NewPostgresConnection(cfg config.DatabaseConfig, password *secrets.Value) (*gorm.DB, error) {
 	dsn := cfg.BuildDSN()
 
 	// Configure GORM logger
 	var gormLogger logger.Interface
 	gormLogger = logger.Default.LogMode(logger.Info)
 
-	// Open database connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	dialector := postgres.Open(dsn)
+	if password != nil {
+		connConfig, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database configuration: %w", err)
+		}
+		dialector = postgres.New(postgres.Config{
+			Conn: stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(_ context.Context, cc *pgx.ConnConfig) error {
+				cc.Password = password.Get()
+				return nil
+			})),
+		})
+	}
+
+	// Open database connection, WaitFor checks that the server is reachable
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: gormLogger,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Trace every query
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	// Get underlying sql.DB to configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -39,46 +67,41 @@ func NewPostgresConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// Configure connection pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-	sqlDB.SetConnMaxIdleTime(time.Minute * 10)
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := sqlDB.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	return db, nil
 }
 
-// NewRedisConnection creates a new Redis connection
-func NewRedisConnection(cfg config.RedisConfig) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
+// NewRedisConnection creates a new Redis client without connecting, use
+// RedisDependency with WaitFor to check it. When password is set, new
+// connections authenticate with its current value.
+func NewRedisConnection(cfg config.RedisConfig, password *secrets.Value) (*redis.Client, error) {
+	opts := &redis.Options{
 		Addr:            cfg.BuildAddress(),
 		Password:        cfg.Password,
 		DB:              cfg.DB,
-		PoolSize:        20,
-		MinIdleConns:    5,
-		MaxIdleConns:    10,
-		ConnMaxIdleTime: time.Minute * 5,
-		ConnMaxLifetime: time.Hour,
-		ReadTimeout:     3 * time.Second,
-		WriteTimeout:    3 * time.Second,
-		DialTimeout:     5 * time.Second,
-	})
-
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	_, err := rdb.Ping(ctx).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		PoolSize:        cfg.PoolSize,
+		MinIdleConns:    cfg.MinIdleConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		PoolTimeout:     cfg.PoolTimeout,
+		ReadTimeout:     cfg.ReadTimeout,
+		WriteTimeout:    cfg.WriteTimeout,
+		DialTimeout:     cfg.DialTimeout,
 	}
+	if password != nil {
+		opts.CredentialsProvider = func() (string, string) {
+			return "", password.Get()
+		}
+	}
+	rdb := redis.NewClient(opts)
+
+	// Trace every command
+	rdb.AddHook(tracing.NewRedisHook())
 
 	return rdb, nil
 }
@@ -91,7 +114,8 @@ type Migration interface {
 
 // Migrator handles database migrations
 type Migrator struct {
-	db *gorm.DB
+	db         *gorm.DB
+	migrations []*SQLMigration
 }
 
 // NewMigrator creates a new migrator instance
@@ -115,12 +139,12 @@ func (m *Migrator) DropSchema(schemaName string) error {
 }
 
 // Health check functions
-func (m *Migrator) HealthCheck() error {
+func (m *Migrator) HealthCheck(ctx context.Context) error {
 	sqlDB, err := m.db.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
 }
 
 // Close database connection
@@ -132,9 +156,36 @@ func (m *Migrator) Close() error {
 	return sqlDB.Close()
 }
 
+// afterCommitKey holds the afterCommit hooks of a WithTransaction transaction in its context
+type afterCommitKey struct{}
+
+type afterCommit struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// AfterCommit runs fn once the WithTransaction transaction tx belongs to has
+// committed, not at all when it rolls back. Outside such a transaction fn runs
+// right away.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	hooks, ok := tx.Statement.Context.Value(afterCommitKey{}).(*afterCommit)
+	if !ok {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
+
 // Transaction helper
 func WithTransaction(db *gorm.DB, fn func(*gorm.DB) error) error {
-	tx := db.Begin()
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hooks := &afterCommit{}
+	tx := db.WithContext(context.WithValue(ctx, afterCommitKey{}, hooks)).Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -151,7 +202,13 @@ func WithTransaction(db *gorm.DB, fn func(*gorm.DB) error) error {
 		return err
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	for _, fn := range hooks.fns {
+		fn()
+	}
+	return nil
 }
 
 // Redis helper functions
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// fakePool is a connection pool that records the statements it receives and
+// answers queries with answer, so tests run without a Postgres server and
+// can tell which pool a statement went to
+type fakePool struct {
+	mu         sync.Mutex
+	statements []string
+	// answer returns the columns and rows of a query, no columns for an empty result
+	answer func(query string) ([]string, [][]driver.Value, error)
+}
+
+// newFakePool returns the pool and a *sql.DB reading from it
+func newFakePool(t *testing.T, answer func(query string) ([]string, [][]driver.Value, error)) (*fakePool, *sql.DB) {
+	t.Helper()
+	p := &fakePool{answer: answer}
+	db := sql.OpenDB(p)
+	t.Cleanup(func() { db.Close() })
+	return p, db
+}
+
+// openFake opens a gorm session on db
+func openFake(t *testing.T, db *sql.DB) *gorm.DB {
+	t.Helper()
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return gdb
+}
+
+// Statements returns the statements run so far
+func (p *fakePool) Statements() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.statements...)
+}
+
+func (p *fakePool) record(query string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statements = append(p.statements, query)
+}
+
+func (p *fakePool) Connect(context.Context) (driver.Conn, error) { return fakeConn{p}, nil }
+func (p *fakePool) Driver() driver.Driver                        { return fakeDriver{p} }
+
+type fakeDriver struct{ pool *fakePool }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d.pool}, nil }
+
+type fakeConn struct{ pool *fakePool }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake pool: prepared statements are not supported")
+}
+func (c fakeConn) Close() error { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.pool.record("BEGIN")
+	return fakeTx{c.pool}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.pool.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.pool.record(query)
+	var columns []string
+	var rows [][]driver.Value
+	if c.pool.answer != nil {
+		var err error
+		if columns, rows, err = c.pool.answer(query); err != nil {
+			return nil, err
+		}
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeTx struct{ pool *fakePool }
+
+func (tx fakeTx) Commit() error   { tx.pool.record("COMMIT"); return nil }
+func (tx fakeTx) Rollback() error { tx.pool.record("ROLLBACK"); return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,380 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	// migrationsTable tracks every applied migration version
+	migrationsTable = "schema_migrations"
+
+	// migrationLockID is the pg_advisory_lock key held while migrating,
+	// so concurrent replicas cannot run migrations at the same time
+	migrationLockID int64 = 7281623450981231
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// SQLMigration is a versioned migration loaded from a pair of .up.sql/.down.sql files
+type SQLMigration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+
+	db *gorm.DB
+}
+
+var _ Migration = (*SQLMigration)(nil)
+
+// Up applies the migration on the transaction it is bound to
+func (m *SQLMigration) Up() error {
+	return m.db.Exec(m.UpSQL).Error
+}
+
+// Down reverts the migration on the transaction it is bound to
+func (m *SQLMigration) Down() error {
+	if m.DownSQL == "" {
+		return fmt.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+	}
+	return m.db.Exec(m.DownSQL).Error
+}
+
+// MigrationStatus describes whether a known migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return migrationsTable
+}
+
+// LoadMigrations reads numbered SQL migrations from fsys, sorted by version
+func LoadMigrations(fsys fs.FS) ([]*SQLMigration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*SQLMigration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &SQLMigration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]*SQLMigration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// LoadMigrations loads the versioned migrations managed by this migrator
+func (m *Migrator) LoadMigrations(fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+	m.migrations = migrations
+	return nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Version returns the highest applied migration version, 0 when none
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]*SQLMigration, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*SQLMigration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	var done []int64
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(db, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) ([]int64, error) {
+	var done []int64
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(db, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Goto migrates up or down until version is the latest applied migration
+func (m *Migrator) Goto(ctx context.Context, version int64) ([]int64, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var done []int64
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		// Revert everything above the target, newest first
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= version {
+				break
+			}
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(db, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration.Version)
+		}
+
+		// Apply everything up to and including the target
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(db, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) find(version int64) *SQLMigration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// apply runs a single migration and records it in one transaction
+func (m *Migrator) apply(db *gorm.DB, migration *SQLMigration, up bool) error {
+	return WithTransaction(db, func(tx *gorm.DB) error {
+		bound := *migration
+		bound.db = tx
+
+		if up {
+			if err := bound.Up(); err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", migration.Version, migration.Name, err)
+			}
+			return tx.Create(&appliedMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		}
+
+		if err := bound.Down(); err != nil {
+			return fmt.Errorf("migration %d_%s down failed: %w", migration.Version, migration.Name, err)
+		}
+		return tx.Delete(&appliedMigration{}, migration.Version).Error
+	})
+}
+
+// applied returns the rows of schema_migrations keyed by version. It only
+// reads, a database without the table has nothing applied.
+func (m *Migrator) applied(db *gorm.DB) (map[int64]appliedMigration, error) {
+	var exists bool
+	if err := db.Raw("SELECT to_regclass(?) IS NOT NULL", migrationsTable).Scan(&exists).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", migrationsTable, err)
+	}
+	if !exists {
+		return map[int64]appliedMigration{}, nil
+	}
+
+	var rows []appliedMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", migrationsTable, err)
+	}
+
+	applied := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", migrationsTable, err)
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	// Advisory locks belong to a session, so pin one connection from the pool
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger:  m.db.Logger,
+		NowFunc: m.db.NowFunc,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open migration session: %w", err)
+	}
+	db = db.WithContext(ctx)
+
+	// Only commands that change the schema create the table
+	if err := m.ensureTable(db); err != nil {
+		return err
+	}
+	return fn(db)
+}
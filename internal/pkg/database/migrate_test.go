@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go-user-service/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000010_add_index.up.sql":     {Data: []byte("CREATE INDEX a ON t (a);")},
+		"000010_add_index.down.sql":   {Data: []byte("DROP INDEX a;")},
+		"000002_create_t.up.sql":      {Data: []byte("CREATE TABLE t (a INT);")},
+		"000002_create_t.down.sql":    {Data: []byte("DROP TABLE t;")},
+		"000003_seed.up.sql":          {Data: []byte("INSERT INTO t VALUES (1);")},
+		"README.md":                   {Data: []byte("not a migration")},
+		"000004_Bad_Name.up.sql":      {Data: []byte("ignored")},
+		"fixtures/000005_data.up.sql": {Data: []byte("ignored")},
+	}
+
+	got, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+
+	want := []struct {
+		version int64
+		name    string
+		up      string
+		down    string
+	}{
+		{2, "create_t", "CREATE TABLE t (a INT);", "DROP TABLE t;"},
+		{3, "seed", "INSERT INTO t VALUES (1);", ""},
+		{10, "add_index", "CREATE INDEX a ON t (a);", "DROP INDEX a;"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("LoadMigrations() returned %d migrations, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		m := got[i]
+		if m.Version != w.version || m.Name != w.name || m.UpSQL != w.up || m.DownSQL != w.down {
+			t.Errorf("migration %d = {%d %s %q %q}, want %v", i, m.Version, m.Name, m.UpSQL, m.DownSQL, w)
+		}
+	}
+}
+
+func TestLoadMigrationsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"000001_init.down.sql": {Data: []byte("DROP TABLE t;")}},
+			want: "migration 1_init has no up script",
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql":  {Data: []byte("CREATE TABLE t (a INT);")},
+				"000001_other.up.sql": {Data: []byte("CREATE TABLE u (a INT);")},
+			},
+			want: "duplicate migration version 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadMigrations() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	got, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range got {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s has version %d, want %d without gaps", m.Version, m.Name, m.Version, i+1)
+		}
+		if m.DownSQL == "" {
+			t.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
+
+func TestPendingOnEmptyDatabase(t *testing.T) {
+	pool, sqlDB := newFakePool(t, func(query string) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "to_regclass") {
+			return []string{"exists"}, [][]driver.Value{{false}}, nil
+		}
+		t.Errorf("unexpected query %q", query)
+		return nil, nil, nil
+	})
+
+	m := NewMigrator(openFake(t, sqlDB))
+	if err := m.LoadMigrations(migrations.FS); err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	all, _ := LoadMigrations(migrations.FS)
+	if len(pending) != len(all) {
+		t.Errorf("Pending() returned %d migrations, want all %d", len(pending), len(all))
+	}
+
+	version, err := m.Version(context.Background())
+	if err != nil || version != 0 {
+		t.Errorf("Version() = %d, %v, want 0", version, err)
+	}
+
+	// Checking must not create schema_migrations
+	for _, stmt := range pool.Statements() {
+		if !strings.Contains(stmt, "to_regclass") {
+			t.Errorf("ran %q, want only the table lookup", stmt)
+		}
+	}
+}
+
+func TestPendingSkipsApplied(t *testing.T) {
+	_, sqlDB := newFakePool(t, func(query string) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "to_regclass") {
+			return []string{"exists"}, [][]driver.Value{{true}}, nil
+		}
+		return []string{"version", "name", "applied_at"}, [][]driver.Value{
+			{int64(1), "create_users_table", time.Now()},
+			{int64(2), "create_outbox_table", time.Now()},
+		}, nil
+	})
+
+	m := NewMigrator(openFake(t, sqlDB))
+	if err := m.LoadMigrations(migrations.FS); err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) == 0 || pending[0].Version != 3 {
+		t.Fatalf("Pending() = %v, want migrations from version 3", pending)
+	}
+	for i := 1; i < len(pending); i++ {
+		if pending[i].Version <= pending[i-1].Version {
+			t.Errorf("Pending() is not sorted by version: %d after %d", pending[i].Version, pending[i-1].Version)
+		}
+	}
+}
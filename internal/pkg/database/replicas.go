@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/secrets"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// replicaLagQuery returns how far behind the primary a replica's replay is, in
+// seconds. A replica that replayed everything it received is not lagging
+// even when the primary has been idle.
+const replicaLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+type primaryKey struct{}
+
+// WithPrimary pins the queries run with ctx to the primary, for writes and
+// for reads that must see them
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was pinned to the primary by WithPrimary
+func UsesPrimary(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+// Replicas sends reads outside transactions to healthy read replicas, in
+// turn. Reads go to the primary when ctx is pinned with WithPrimary, when
+// they lock rows, or when no replica is healthy.
+type Replicas struct {
+	primary  gorm.ConnPool
+	replicas []*replica
+	cfg      config.DatabaseConfig
+	logger   *logger.Logger
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+	// checked is set after the first check, only Monitor uses it
+	checked bool
+}
+
+// UseReplicas opens the replicas of cfg and routes the reads of db to them.
+// Replicas only join the rotation once Monitor found them healthy. When
+// password is set, replica DSNs without a password use its current value.
+func UseReplicas(db *gorm.DB, cfg config.DatabaseConfig, password *secrets.Value, l *logger.Logger) (*Replicas, error) {
+	r := &Replicas{primary: db.ConnPool, cfg: cfg, logger: l}
+	for _, dsn := range cfg.ReplicaDSNs() {
+		connConfig, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("invalid replica DSN: %w", err)
+		}
+		usePrimaryPassword := connConfig.Password == "" && password != nil
+		sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(_ context.Context, cc *pgx.ConnConfig) error {
+			if usePrimaryPassword {
+				cc.Password = password.Get()
+			}
+			return nil
+		}))
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+		r.replicas = append(r.replicas, &replica{
+			name: fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port),
+			db:   sqlDB,
+		})
+	}
+	if len(r.replicas) == 0 {
+		return r, nil
+	}
+
+	if err := r.register(db); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// register routes the query and row statements of db through route
+func (r *Replicas) register(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("replicas:route", r.route); err != nil {
+		return fmt.Errorf("failed to register replica routing: %w", err)
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("replicas:route", r.route); err != nil {
+		return fmt.Errorf("failed to register replica routing: %w", err)
+	}
+	return nil
+}
+
+// route switches a read statement to a replica
+func (r *Replicas) route(db *gorm.DB) {
+	stmt := db.Statement
+	// Transactions and sessions bound to a connection already left the primary pool
+	if stmt.ConnPool != r.primary {
+		return
+	}
+	if stmt.Context != nil && UsesPrimary(stmt.Context) {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	// Raw SQL is only routed when it is a plain SELECT
+	if raw := strings.TrimSpace(stmt.SQL.String()); raw != "" && !strings.HasPrefix(strings.ToUpper(raw), "SELECT") {
+		return
+	}
+	if pool := r.pick(); pool != nil {
+		stmt.ConnPool = pool
+	}
+}
+
+// pick returns the next healthy replica, nil when there is none
+func (r *Replicas) pick() gorm.ConnPool {
+	n := len(r.replicas)
+	start := int(r.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return nil
+}
+
+// Monitor checks every replica each ReplicaCheckInterval until ctx is done.
+// Replicas that cannot be reached or lag more than ReplicaMaxLag are taken
+// out of rotation until a later check passes.
+func (r *Replicas) Monitor(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(r.cfg.ReplicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, rep := range r.replicas {
+			r.check(ctx, rep)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Replicas) check(ctx context.Context, rep *replica) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.ConnectTimeout)
+	defer cancel()
+
+	var seconds float64
+	err := rep.db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+	if err == nil && lag > r.cfg.ReplicaMaxLag {
+		err = fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), r.cfg.ReplicaMaxLag)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	healthy := err == nil
+	if rep.healthy.Swap(healthy) == healthy && rep.checked {
+		return
+	}
+	rep.checked = true
+	entry := r.logger.WithFields(logger.Fields{
+		"replica": rep.name,
+		"lag_ms":  lag.Milliseconds(),
+		"type":    "database",
+	})
+	if healthy {
+		entry.Info("Replica added to rotation")
+	} else {
+		entry.WithError(err).Warn("Replica removed from rotation")
+	}
+}
+
+// Close closes the replica connections
+func (r *Replicas) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+	return errors.Join(errs...)
+}
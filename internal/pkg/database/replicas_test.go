@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+// lagAnswer answers the lag query of a replica with lag, or fails with err
+func lagAnswer(lag *time.Duration, err *error) func(string) ([]string, [][]driver.Value, error) {
+	return func(query string) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "pg_last_wal_replay_lsn") {
+			if *err != nil {
+				return nil, nil, *err
+			}
+			return []string{"lag"}, [][]driver.Value{{lag.Seconds()}}, nil
+		}
+		return nil, nil, nil
+	}
+}
+
+// replicaSet is a primary and its replicas on fake pools
+type replicaSet struct {
+	db       *gorm.DB
+	replicas *Replicas
+	primary  *fakePool
+	pools    []*fakePool
+	lags     []time.Duration
+	errs     []error
+}
+
+func newReplicaSet(t *testing.T, n int) *replicaSet {
+	t.Helper()
+	primary, primaryDB := newFakePool(t, nil)
+	db := openFake(t, primaryDB)
+
+	s := &replicaSet{
+		db:      db,
+		primary: primary,
+		lags:    make([]time.Duration, n),
+		errs:    make([]error, n),
+		replicas: &Replicas{
+			primary: db.ConnPool,
+			cfg: config.DatabaseConfig{
+				ConnectTimeout: time.Second,
+				ReplicaMaxLag:  10 * time.Second,
+			},
+			logger: logger.New("panic", "test"),
+		},
+	}
+	for i := 0; i < n; i++ {
+		pool, sqlDB := newFakePool(t, lagAnswer(&s.lags[i], &s.errs[i]))
+		s.pools = append(s.pools, pool)
+		s.replicas.replicas = append(s.replicas.replicas, &replica{name: fmt.Sprintf("replica-%d", i), db: sqlDB})
+	}
+	if err := s.replicas.register(db); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	return s
+}
+
+// check runs one Monitor round
+func (s *replicaSet) check() {
+	for _, rep := range s.replicas.replicas {
+		s.replicas.check(context.Background(), rep)
+	}
+}
+
+// used returns how many statements on items each pool received, the primary first
+func (s *replicaSet) used() []int {
+	count := func(p *fakePool) int {
+		n := 0
+		for _, stmt := range p.Statements() {
+			if strings.Contains(stmt, "items") {
+				n++
+			}
+		}
+		return n
+	}
+	counts := []int{count(s.primary)}
+	for _, pool := range s.pools {
+		counts = append(counts, count(pool))
+	}
+	return counts
+}
+
+func TestReplicasRouting(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB) error
+		// wantReplica is set for statements that should leave the primary
+		wantReplica bool
+	}{
+		{
+			name:        "find",
+			run:         func(db *gorm.DB) error { return db.Find(&[]item{}).Error },
+			wantReplica: true,
+		},
+		{
+			name: "row",
+			run: func(db *gorm.DB) error {
+				return db.Model(&item{}).Select("count(*)").Row().Err()
+			},
+			wantReplica: true,
+		},
+		{
+			name:        "raw select",
+			run:         func(db *gorm.DB) error { return db.Raw("SELECT * FROM items").Scan(&[]item{}).Error },
+			wantReplica: true,
+		},
+		{
+			name: "pinned to the primary",
+			run: func(db *gorm.DB) error {
+				return db.WithContext(WithPrimary(context.Background())).Find(&[]item{}).Error
+			},
+		},
+		{
+			name: "locking read",
+			run: func(db *gorm.DB) error {
+				return db.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&[]item{}).Error
+			},
+		},
+		{
+			name: "read in a transaction",
+			run: func(db *gorm.DB) error {
+				return db.Transaction(func(tx *gorm.DB) error { return tx.Find(&[]item{}).Error })
+			},
+		},
+		{
+			name: "update",
+			run: func(db *gorm.DB) error {
+				return db.Model(&item{}).Where("id = ?", 1).Update("name", "budi").Error
+			},
+		},
+		{
+			name: "delete",
+			run:  func(db *gorm.DB) error { return db.Delete(&item{}, 1).Error },
+		},
+		{
+			name: "raw write returning rows",
+			run: func(db *gorm.DB) error {
+				return db.Raw("UPDATE items SET name = ? RETURNING id", "budi").Scan(&[]item{}).Error
+			},
+		},
+		{
+			name: "exec",
+			run:  func(db *gorm.DB) error { return db.Exec("DELETE FROM items").Error },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newReplicaSet(t, 1)
+			s.check()
+
+			if err := tt.run(s.db); err != nil {
+				t.Fatalf("statement error = %v", err)
+			}
+
+			want := []int{1, 0}
+			if tt.wantReplica {
+				want = []int{0, 1}
+			}
+			if got := s.used(); got[0] != want[0] || got[1] != want[1] {
+				t.Errorf("statements on [primary replica] = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReplicasRoundRobin(t *testing.T) {
+	s := newReplicaSet(t, 2)
+	s.check()
+
+	for i := 0; i < 4; i++ {
+		if err := s.db.Find(&[]item{}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := s.used(); got[0] != 0 || got[1] != 2 || got[2] != 2 {
+		t.Errorf("statements on [primary replicas...] = %v, want [0 2 2]", got)
+	}
+}
+
+func TestReplicasRotation(t *testing.T) {
+	s := newReplicaSet(t, 2)
+	read := func() {
+		t.Helper()
+		if err := s.db.Find(&[]item{}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name string
+		lags []time.Duration
+		errs []error
+		// want counts the statements of two reads on [primary replica-0 replica-1]
+		want []int
+	}{
+		{
+			name: "all healthy",
+			lags: []time.Duration{0, 2 * time.Second},
+			errs: []error{nil, nil},
+			want: []int{0, 1, 1},
+		},
+		{
+			name: "first replica lagging",
+			lags: []time.Duration{time.Minute, 0},
+			errs: []error{nil, nil},
+			want: []int{0, 0, 2},
+		},
+		{
+			name: "second replica unreachable",
+			lags: []time.Duration{time.Minute, 0},
+			errs: []error{nil, errors.New("connection refused")},
+			want: []int{2, 0, 0},
+		},
+		{
+			name: "first replica caught up",
+			lags: []time.Duration{5 * time.Second, 0},
+			errs: []error{nil, errors.New("connection refused")},
+			want: []int{0, 2, 0},
+		},
+	}
+
+	previous := s.used()
+	for _, step := range steps {
+		copy(s.lags, step.lags)
+		copy(s.errs, step.errs)
+		s.check()
+
+		read()
+		read()
+
+		got := s.used()
+		for i := range got {
+			if got[i]-previous[i] != step.want[i] {
+				t.Errorf("%s: pool %d ran %d reads, want %d", step.name, i, got[i]-previous[i], step.want[i])
+			}
+		}
+		previous = got
+	}
+}
+
+func TestReplicasUncheckedStayOut(t *testing.T) {
+	s := newReplicaSet(t, 1)
+
+	// Replicas only join the rotation once a check passed
+	if err := s.db.Find(&[]item{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := s.used(); got[0] != 1 || got[1] != 0 {
+		t.Errorf("statements on [primary replica] = %v, want the unchecked replica skipped", got)
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/logger"
+
+	redis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Dependency is a service that startup waits for
+type Dependency struct {
+	Name string
+	// Ping returns nil once the dependency is reachable
+	Ping func(ctx context.Context) error
+}
+
+// PostgresDependency pings db, each attempt bounded by timeout
+func PostgresDependency(db *gorm.DB, timeout time.Duration) Dependency {
+	return Dependency{
+		Name: "postgres",
+		Ping: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return NewMigrator(db).HealthCheck(ctx)
+		},
+	}
+}
+
+// RedisDependency pings rdb, each attempt bounded by timeout
+func RedisDependency(rdb *redis.Client, timeout time.Duration) Dependency {
+	return Dependency{
+		Name: "redis",
+		Ping: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return rdb.Ping(ctx).Err()
+		},
+	}
+}
+
+// WaitFor pings every dependency concurrently until it answers, sleeping with
+// exponential backoff and jitter between attempts. It gives up once
+// cfg.Deadline has passed and returns the last error of every dependency that
+// is still down.
+func WaitFor(ctx context.Context, cfg config.StartupConfig, l *logger.Logger, deps ...Dependency) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Deadline)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		problems []error
+	)
+	for _, dep := range deps {
+		wg.Add(1)
+		go func(dep Dependency) {
+			defer wg.Done()
+			if err := waitFor(ctx, cfg, l, dep); err != nil {
+				mu.Lock()
+				problems = append(problems, err)
+				mu.Unlock()
+			}
+		}(dep)
+	}
+	wg.Wait()
+
+	return errors.Join(problems...)
+}
+
+func waitFor(ctx context.Context, cfg config.StartupConfig, l *logger.Logger, dep Dependency) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := dep.Ping(ctx)
+		entry := l.WithFields(logger.Fields{
+			"dependency": dep.Name,
+			"attempt":    attempt,
+			"elapsed_ms": time.Since(start).Milliseconds(),
+			"type":       "startup",
+		})
+		if err == nil {
+			entry.Info("Dependency is available")
+			return nil
+		}
+
+		delay := startupBackoff(cfg, attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			entry.WithError(err).Error("Dependency still unavailable at the startup deadline")
+			return fmt.Errorf("%s unavailable after %d attempt(s): %w", dep.Name, attempt, err)
+		}
+		entry.WithError(err).WithField("retry_in_ms", delay.Milliseconds()).Warn("Dependency unavailable, retrying")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s unavailable after %d attempt(s): %w", dep.Name, attempt, err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// startupBackoff doubles cfg.InitialBackoff for every failed attempt up to cfg.MaxBackoff
+func startupBackoff(cfg config.StartupConfig, attempt int) time.Duration {
+	backoff := cfg.InitialBackoff
+	for i := 1; i < attempt && backoff < cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	// Up to 20% jitter so replicas restarting together do not retry in lockstep
+	return backoff - time.Duration(rand.Int63n(int64(backoff)/5+1))
+}
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// PostgreSQL SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgQueryCanceled        = "57014"
+)
+
+// Classify translates driver and context errors into an AppError that keeps
+// err as its cause. AppErrors are returned as is, and nil is returned when
+// err is not recognised so callers can pick their own fallback.
+func Classify(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *AppError
+	if As(err, &appErr) {
+		return appErr
+	}
+
+	var pgErr *pgconn.PgError
+	if As(err, &pgErr) {
+		return classifyPostgres(err, pgErr)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if As(err, &maxBytesErr) {
+		return wrap(err, ErrCodePayloadTooLarge, "Request body is too large").WithMetadata("max_bytes", maxBytesErr.Limit)
+	}
+
+	switch {
+	case Is(err, context.Canceled):
+		return wrap(err, ErrCodeCanceled, "Request canceled")
+	case Is(err, context.DeadlineExceeded):
+		return wrap(err, ErrCodeTimeout, "Operation timed out")
+	case Is(err, gorm.ErrRecordNotFound), Is(err, redis.Nil):
+		return wrap(err, ErrCodeNotFound, "Resource not found")
+	case Is(err, redis.ErrClosed):
+		return wrap(err, ErrCodeUnavailable, "Service temporarily unavailable")
+	case isTimeout(err):
+		return wrap(err, ErrCodeTimeout, "Operation timed out")
+	}
+	return nil
+}
+
+// classifyPostgres maps a server error by SQLSTATE. Constraint names stay in
+// the cause only, they describe the schema and are not sent to clients.
+func classifyPostgres(err error, pgErr *pgconn.PgError) *AppError {
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return wrap(err, ErrCodeAlreadyExists, "Resource already exists")
+	case pgForeignKeyViolation:
+		return wrap(err, ErrCodeConflict, "Resource is referenced by or refers to a missing resource")
+	case pgSerializationFailure, pgDeadlockDetected:
+		return wrap(err, ErrCodeConflict, "Concurrent update, try again").WithRetryable(true)
+	case pgQueryCanceled:
+		return wrap(err, ErrCodeTimeout, "Operation timed out")
+	default:
+		return wrap(err, ErrCodeDatabase, "Database error")
+	}
+}
+
+// isTimeout matches network timeouts, which go-redis and pgx return as is
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return As(err, &netErr) && netErr.Timeout()
+}
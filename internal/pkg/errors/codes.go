@@ -0,0 +1,65 @@
+package errors
+
+import "net/http"
+
+// Definition documents one error code for client developers
+type Definition struct {
+	Code        ErrorCode `json:"code"`
+	Status      int       `json:"status"`
+	Retryable   bool      `json:"retryable"`
+	Description string    `json:"description"`
+}
+
+// definitions is the single source of the HTTP status and default
+// retryability of every code, and backs the GET /errors catalog
+var definitions = []Definition{
+	{ErrCodeValidation, http.StatusBadRequest, false, "The request is malformed or a field failed validation, see fields."},
+	{ErrCodeUnauthorized, http.StatusUnauthorized, false, "The request has no valid credentials."},
+	{ErrCodeForbidden, http.StatusForbidden, false, "The credentials are valid but do not allow this action."},
+	{ErrCodeNotFound, http.StatusNotFound, false, "The requested resource does not exist."},
+	{ErrCodeAlreadyExists, http.StatusConflict, false, "A resource with the same unique attributes already exists."},
+	{ErrCodeConflict, http.StatusConflict, false, "The request conflicts with the current state of the resource, reload it and try again."},
+	{ErrCodePreconditionFailed, http.StatusPreconditionFailed, false, "A precondition such as If-Match did not hold."},
+	{ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge, false, "The request body exceeds the allowed size."},
+	{ErrCodeUnsupportedMedia, http.StatusUnsupportedMediaType, false, "The request body has an unsupported content type."},
+	{ErrCodeUnprocessable, http.StatusUnprocessableEntity, false, "The request is well-formed but cannot be processed."},
+	{ErrCodeRateLimited, http.StatusTooManyRequests, true, "Too many requests, retry after the delay given in metadata or Retry-After."},
+	{ErrCodeCanceled, 499, false, "The client canceled the request before it completed."},
+	{ErrCodeInternal, http.StatusInternalServerError, false, "An unexpected server error occurred."},
+	{ErrCodeDatabase, http.StatusInternalServerError, false, "The database failed to complete the operation."},
+	{ErrCodeNotImplemented, http.StatusNotImplemented, false, "The operation is not supported by this server."},
+	{ErrCodeExternal, http.StatusBadGateway, true, "An upstream service returned an error."},
+	{ErrCodeUnavailable, http.StatusServiceUnavailable, true, "The service or one of its dependencies is temporarily unavailable."},
+	{ErrCodeTimeout, http.StatusGatewayTimeout, true, "The operation did not complete in time."},
+}
+
+var definitionsByCode = func() map[ErrorCode]Definition {
+	byCode := make(map[ErrorCode]Definition, len(definitions))
+	for _, def := range definitions {
+		byCode[def.Code] = def
+	}
+	return byCode
+}()
+
+// Definitions returns every error code with its status and description
+func Definitions() []Definition {
+	return append([]Definition(nil), definitions...)
+}
+
+// Lookup returns the definition of code
+func Lookup(code ErrorCode) (Definition, bool) {
+	def, ok := definitionsByCode[code]
+	return def, ok
+}
+
+// Helper functions
+func getHTTPStatusCode(code ErrorCode) int {
+	if def, ok := definitionsByCode[code]; ok {
+		return def.Status
+	}
+	return http.StatusInternalServerError
+}
+
+func isRetryable(code ErrorCode) bool {
+	return definitionsByCode[code].Retryable
+}
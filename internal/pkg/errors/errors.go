@@ -4,7 +4,8 @@ package errors
 import (
 	"errors"
 	"fmt"
-	"net/http"
+	"runtime"
+	"strings"
 )
 
 // ErrorCode represents standardized error codes
@@ -12,19 +13,31 @@ type ErrorCode string
 
 const (
 	// Business Logic Errors
-	ErrCodeValidation    ErrorCode = "VALIDATION_ERROR"
-	ErrCodeNotFound      ErrorCode = "NOT_FOUND"
-	ErrCodeAlreadyExists ErrorCode = "ALREADY_EXISTS"
-	ErrCodeUnauthorized  ErrorCode = "UNAUTHORIZED"
-	ErrCodeForbidden     ErrorCode = "FORBIDDEN"
+	ErrCodeValidation         ErrorCode = "VALIDATION_ERROR"
+	ErrCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrCodeAlreadyExists      ErrorCode = "ALREADY_EXISTS"
+	ErrCodeConflict           ErrorCode = "CONFLICT"
+	ErrCodePreconditionFailed ErrorCode = "PRECONDITION_FAILED"
+	ErrCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	ErrCodeForbidden          ErrorCode = "FORBIDDEN"
+	ErrCodeRateLimited        ErrorCode = "RATE_LIMITED"
+	ErrCodePayloadTooLarge    ErrorCode = "PAYLOAD_TOO_LARGE"
+	ErrCodeUnsupportedMedia   ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeUnprocessable      ErrorCode = "UNPROCESSABLE_ENTITY"
+	ErrCodeCanceled           ErrorCode = "CANCELED"
 
 	// System Errors
-	ErrCodeDatabase ErrorCode = "DATABASE_ERROR"
-	ErrCodeExternal ErrorCode = "EXTERNAL_SERVICE_ERROR"
-	ErrCodeInternal ErrorCode = "INTERNAL_ERROR"
-	ErrCodeTimeout  ErrorCode = "TIMEOUT_ERROR"
+	ErrCodeDatabase       ErrorCode = "DATABASE_ERROR"
+	ErrCodeExternal       ErrorCode = "EXTERNAL_SERVICE_ERROR"
+	ErrCodeInternal       ErrorCode = "INTERNAL_ERROR"
+	ErrCodeTimeout        ErrorCode = "TIMEOUT_ERROR"
+	ErrCodeUnavailable    ErrorCode = "UNAVAILABLE"
+	ErrCodeNotImplemented ErrorCode = "NOT_IMPLEMENTED"
 )
 
+// maxStackDepth bounds the frames captured by Wrap
+const maxStackDepth = 32
+
 // AppError represents application-specific error
 type AppError struct {
 	Code       ErrorCode `json:"code"`
@@ -32,6 +45,12 @@ type AppError struct {
 	Details    string    `json:"details,omitempty"`
 	StatusCode int       `json:"-"`
 	Cause      error     `json:"-"`
+	// Retryable tells clients the same request may succeed later
+	Retryable bool `json:"retryable"`
+	// Metadata carries structured context, e.g. the limit that was hit
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	stack []uintptr
 }
 
 func (e *AppError) Error() string {
@@ -45,46 +64,107 @@ func (e *AppError) Unwrap() error {
 	return e.Cause
 }
 
+// WithMetadata adds a metadata entry and returns e for chaining
+func (e *AppError) WithMetadata(key string, value interface{}) *AppError {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]interface{})
+	}
+	e.Metadata[key] = value
+	return e
+}
+
+// WithDetails sets the details and returns e for chaining
+func (e *AppError) WithDetails(details string) *AppError {
+	e.Details = details
+	return e
+}
+
+// WithRetryable overrides the default retryability of the code
+func (e *AppError) WithRetryable(retryable bool) *AppError {
+	e.Retryable = retryable
+	return e
+}
+
+// StackTrace returns the call stack captured by Wrap, one "function file:line" per line
+func (e *AppError) StackTrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
 // Error constructors
 func New(code ErrorCode, message string) *AppError {
 	return &AppError{
 		Code:       code,
 		Message:    message,
 		StatusCode: getHTTPStatusCode(code),
+		Retryable:  isRetryable(code),
 	}
 }
 
+// Wrap attaches code and message to err and captures the caller's stack.
+// A generic code gives way to the one Classify finds for err, so a timeout
+// wrapped as a database error still reaches the client as a timeout.
 func Wrap(err error, code ErrorCode, message string) *AppError {
-	return &AppError{
-		Code:       code,
-		Message:    message,
-		StatusCode: getHTTPStatusCode(code),
-		Cause:      err,
-	}
+	return refine(wrap(err, code, message))
 }
 
 func Wrapf(err error, code ErrorCode, format string, args ...interface{}) *AppError {
-	return Wrap(err, code, fmt.Sprintf(format, args...))
-}
-
-// Helper functions
-func getHTTPStatusCode(code ErrorCode) int {
-	switch code {
-	case ErrCodeValidation:
-		return http.StatusBadRequest
-	case ErrCodeNotFound:
-		return http.StatusNotFound
-	case ErrCodeAlreadyExists:
-		return http.StatusConflict
-	case ErrCodeUnauthorized:
-		return http.StatusUnauthorized
-	case ErrCodeForbidden:
-		return http.StatusForbidden
-	case ErrCodeDatabase, ErrCodeExternal, ErrCodeInternal, ErrCodeTimeout:
-		return http.StatusInternalServerError
-	default:
-		return http.StatusInternalServerError
+	return refine(wrap(err, code, fmt.Sprintf(format, args...)))
+}
+
+// genericCodes are the codes callers wrap errors with without inspecting
+// them: fallbacks for unexpected failures, and validation for request
+// decoding, which can also fail on an oversized or abandoned body
+var genericCodes = map[ErrorCode]bool{
+	ErrCodeDatabase:   true,
+	ErrCodeInternal:   true,
+	ErrCodeValidation: true,
+}
+
+// refine replaces a generic code with the more specific classification of
+// the cause, keeping the cause and the captured stack
+func refine(appErr *AppError) *AppError {
+	if !genericCodes[appErr.Code] {
+		return appErr
+	}
+	classified := Classify(appErr.Cause)
+	if classified == nil || genericCodes[classified.Code] {
+		return appErr
+	}
+
+	appErr.Code = classified.Code
+	appErr.Message = classified.Message
+	appErr.StatusCode = classified.StatusCode
+	appErr.Retryable = classified.Retryable
+	if appErr.Details == "" {
+		appErr.Details = classified.Details
+	}
+	for key, value := range classified.Metadata {
+		appErr.WithMetadata(key, value)
 	}
+	return appErr
+}
+
+func wrap(err error, code ErrorCode, message string) *AppError {
+	appErr := New(code, message)
+	appErr.Cause = err
+
+	// Skip runtime.Callers, wrap and the exported constructor
+	pcs := make([]uintptr, maxStackDepth)
+	appErr.stack = pcs[:runtime.Callers(3, pcs)]
+	return appErr
 }
 
 // IsErrorCode checks if error has specific code
@@ -96,6 +176,15 @@ func IsErrorCode(err error, code ErrorCode) bool {
 	return false
 }
 
+// IsRetryable reports whether err is an AppError marked as retryable
+func IsRetryable(err error) bool {
+	var appErr *AppError
+	if As(err, &appErr) {
+		return appErr.Retryable
+	}
+	return false
+}
+
 // As is wrapper for errors.As
 func As(err error, target interface{}) bool {
 	return errors.As(err, target)
@@ -0,0 +1,180 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestWrapClassifiesGenericCodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		cause         error
+		code          ErrorCode
+		wantCode      ErrorCode
+		wantStatus    int
+		wantRetryable bool
+	}{
+		{
+			name:          "deadline exceeded",
+			cause:         fmt.Errorf("query users: %w", context.DeadlineExceeded),
+			code:          ErrCodeDatabase,
+			wantCode:      ErrCodeTimeout,
+			wantStatus:    http.StatusGatewayTimeout,
+			wantRetryable: true,
+		},
+		{
+			name:       "canceled",
+			cause:      context.Canceled,
+			code:       ErrCodeDatabase,
+			wantCode:   ErrCodeCanceled,
+			wantStatus: 499,
+		},
+		{
+			name:          "serialization failure",
+			cause:         fmt.Errorf("commit: %w", &pgconn.PgError{Code: pgSerializationFailure}),
+			code:          ErrCodeDatabase,
+			wantCode:      ErrCodeConflict,
+			wantStatus:    http.StatusConflict,
+			wantRetryable: true,
+		},
+		{
+			name:          "deadlock",
+			cause:         &pgconn.PgError{Code: pgDeadlockDetected},
+			code:          ErrCodeInternal,
+			wantCode:      ErrCodeConflict,
+			wantStatus:    http.StatusConflict,
+			wantRetryable: true,
+		},
+		{
+			name:       "unique violation",
+			cause:      &pgconn.PgError{Code: pgUniqueViolation},
+			code:       ErrCodeDatabase,
+			wantCode:   ErrCodeAlreadyExists,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "wrapped app error",
+			cause:      New(ErrCodeNotFound, "User not found"),
+			code:       ErrCodeInternal,
+			wantCode:   ErrCodeNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "body too large while decoding",
+			cause:      fmt.Errorf("bind: %w", &http.MaxBytesError{Limit: 1024}),
+			code:       ErrCodeValidation,
+			wantCode:   ErrCodePayloadTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "malformed body stays a validation error",
+			cause:      fmt.Errorf("invalid character 'x' looking for beginning of value"),
+			code:       ErrCodeValidation,
+			wantCode:   ErrCodeValidation,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "other postgres error stays a database error",
+			cause:      &pgconn.PgError{Code: "42P01"},
+			code:       ErrCodeDatabase,
+			wantCode:   ErrCodeDatabase,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "unrecognised cause keeps the code",
+			cause:      fmt.Errorf("connection reset"),
+			code:       ErrCodeDatabase,
+			wantCode:   ErrCodeDatabase,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "specific codes are kept",
+			cause:      gorm.ErrRecordNotFound,
+			code:       ErrCodeUnauthorized,
+			wantCode:   ErrCodeUnauthorized,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "specific codes are kept for timeouts too",
+			cause:         context.DeadlineExceeded,
+			code:          ErrCodeExternal,
+			wantCode:      ErrCodeExternal,
+			wantStatus:    http.StatusBadGateway,
+			wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(tt.cause, tt.code, "Failed to load user")
+
+			if err.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", err.Code, tt.wantCode)
+			}
+			if err.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", err.StatusCode, tt.wantStatus)
+			}
+			if err.Retryable != tt.wantRetryable {
+				t.Errorf("Retryable = %v, want %v", err.Retryable, tt.wantRetryable)
+			}
+			if !Is(err, tt.cause) {
+				t.Errorf("Wrap() lost the cause %v", tt.cause)
+			}
+			if tt.wantCode == tt.code && err.Message != "Failed to load user" {
+				t.Errorf("Message = %q, want the caller's message", err.Message)
+			}
+			if !strings.Contains(err.StackTrace(), "TestWrapClassifiesGenericCodes") {
+				t.Errorf("stack does not start at the caller:\n%s", err.StackTrace())
+			}
+		})
+	}
+}
+
+func TestWrapfClassifies(t *testing.T) {
+	err := Wrapf(context.DeadlineExceeded, ErrCodeDatabase, "Failed to load user %d", 42)
+	if err.Code != ErrCodeTimeout {
+		t.Errorf("Code = %s, want %s", err.Code, ErrCodeTimeout)
+	}
+	if !strings.Contains(err.StackTrace(), "TestWrapfClassifies") {
+		t.Errorf("stack does not start at the caller:\n%s", err.StackTrace())
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode ErrorCode
+	}{
+		{"nil", nil, ""},
+		{"unrecognised", fmt.Errorf("boom"), ""},
+		{"record not found", gorm.ErrRecordNotFound, ErrCodeNotFound},
+		{"query canceled", &pgconn.PgError{Code: pgQueryCanceled}, ErrCodeTimeout},
+		{"foreign key violation", &pgconn.PgError{Code: pgForeignKeyViolation}, ErrCodeConflict},
+		{"body too large", fmt.Errorf("bind: %w", &http.MaxBytesError{Limit: 1024}), ErrCodePayloadTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err)
+			if tt.wantCode == "" {
+				if got != nil {
+					t.Errorf("Classify() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Code != tt.wantCode {
+				t.Fatalf("Classify() = %v, want %s", got, tt.wantCode)
+			}
+			if !Is(got, tt.err) {
+				t.Error("Classify() lost the cause")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DeadLetterSuffix is appended to a topic to name its dead-letter stream
+const DeadLetterSuffix = ":dlq"
+
+// Message is a single event travelling on the bus
+type Message struct {
+	// ID is the producer-assigned identifier, consumers use it for idempotency
+	ID      string            `json:"id"`
+	Topic   string            `json:"topic"`
+	Payload []byte            `json:"payload"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Set by subscribers
+	StreamID   string `json:"-"`
+	Deliveries int64  `json:"-"`
+}
+
+// Handler processes a message, returning an error to have it retried
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher appends messages to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg *Message) error
+}
+
+// Subscriber consumes a topic as part of a consumer group.
+// Subscribe blocks until ctx is cancelled.
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic, group string, handler Handler) error
+}
+
+// RetryPolicy bounds how often a failing message is retried before it is dead-lettered
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries five times with backoff from 500ms up to 30s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     5,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+}
+
+// Backoff returns the delay before the given retry (1-based), with jitter
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	// Up to 20% jitter so retries from many consumers spread out
+	return backoff - time.Duration(rand.Int63n(int64(backoff)/5+1))
+}
+
+// DeadLetterTopic returns the dead-letter topic for topic
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// deliver runs handler with bounded in-process retries.
+// It returns the last error once the retries are exhausted.
+func deliver(ctx context.Context, policy RetryPolicy, handler Handler, msg *Message) error {
+	var err error
+	for retry := 0; retry <= policy.MaxRetries; retry++ {
+		if retry > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(policy.Backoff(retry)):
+			}
+		}
+
+		if err = safeHandle(ctx, handler, msg); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// safeHandle turns a handler panic into an error so the message can be retried
+func safeHandle(ctx context.Context, handler Handler, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
@@ -0,0 +1,320 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRetry keeps retries fast enough for tests
+var testRetry = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+// busFactory creates a fresh bus for one contract test
+type busFactory func(t *testing.T, retry RetryPolicy) (Publisher, Subscriber)
+
+// collector records the messages a handler received
+type collector struct {
+	mu       sync.Mutex
+	messages []*Message
+	arrived  chan struct{}
+}
+
+func newCollector() *collector {
+	return &collector{arrived: make(chan struct{}, 1000)}
+}
+
+func (c *collector) handle(_ context.Context, msg *Message) error {
+	c.mu.Lock()
+	c.messages = append(c.messages, msg)
+	c.mu.Unlock()
+	c.arrived <- struct{}{}
+	return nil
+}
+
+// wait blocks until n messages arrived in total
+func (c *collector) wait(t *testing.T, n int) []*Message {
+	t.Helper()
+	for {
+		c.mu.Lock()
+		got := len(c.messages)
+		c.mu.Unlock()
+		if got >= n {
+			break
+		}
+		select {
+		case <-c.arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d message(s), want %d", got, n)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Message(nil), c.messages...)
+}
+
+// subscribe runs sub until the test ends
+func subscribe(t *testing.T, sub Subscriber, topic, group string, handler Handler) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sub.Subscribe(ctx, topic, group, handler) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Subscribe() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Subscribe() did not return after cancel")
+		}
+	})
+}
+
+func publish(t *testing.T, pub Publisher, topic string, msgs ...*Message) {
+	t.Helper()
+	for _, msg := range msgs {
+		if err := pub.Publish(context.Background(), topic, msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if msg.StreamID == "" {
+			t.Fatal("Publish() did not set StreamID")
+		}
+	}
+}
+
+func testMessages(n int) []*Message {
+	msgs := make([]*Message, n)
+	for i := range msgs {
+		msgs[i] = &Message{
+			ID:      fmt.Sprintf("msg-%d", i),
+			Payload: []byte(fmt.Sprintf(`{"n":%d}`, i)),
+			Headers: map[string]string{"source": "test"},
+		}
+	}
+	return msgs
+}
+
+// testBusContract checks the behaviour every Publisher and Subscriber must share
+func testBusContract(t *testing.T, newBus busFactory) {
+	t.Run("delivers every message to every group", func(t *testing.T) {
+		pub, sub := newBus(t, testRetry)
+		publish(t, pub, "users", testMessages(3)...)
+
+		for _, group := range []string{"mailer", "audit"} {
+			c := newCollector()
+			subscribe(t, sub, "users", group, c.handle)
+
+			// Subscribers may handle messages concurrently, so order is not part of the contract
+			got := c.wait(t, 3)
+			byID := make(map[string]*Message, len(got))
+			for _, msg := range got {
+				byID[msg.ID] = msg
+			}
+			for i := 0; i < 3; i++ {
+				msg, ok := byID[fmt.Sprintf("msg-%d", i)]
+				if !ok {
+					t.Errorf("%s: message %d not delivered", group, i)
+					continue
+				}
+				if string(msg.Payload) != fmt.Sprintf(`{"n":%d}`, i) {
+					t.Errorf("%s: message %d payload = %s", group, i, msg.Payload)
+				}
+				if msg.Topic != "users" || msg.StreamID == "" {
+					t.Errorf("%s: message %d topic = %q, stream ID = %q", group, i, msg.Topic, msg.StreamID)
+				}
+				if msg.Headers["source"] != "test" {
+					t.Errorf("%s: message %d headers = %v", group, i, msg.Headers)
+				}
+				if msg.Deliveries != 1 {
+					t.Errorf("%s: message %d deliveries = %d, want 1", group, i, msg.Deliveries)
+				}
+			}
+		}
+	})
+
+	t.Run("shares a group between its consumers", func(t *testing.T) {
+		pub, sub := newBus(t, testRetry)
+
+		c := newCollector()
+		subscribe(t, sub, "users", "mailer", c.handle)
+		subscribe(t, sub, "users", "mailer", c.handle)
+		publish(t, pub, "users", testMessages(20)...)
+
+		got := c.wait(t, 20)
+		// Give a duplicate delivery the chance to show up
+		time.Sleep(50 * time.Millisecond)
+		got = c.wait(t, len(got))
+		seen := make(map[string]bool)
+		for _, msg := range got {
+			if seen[msg.ID] {
+				t.Errorf("message %s delivered twice within the group", msg.ID)
+			}
+			seen[msg.ID] = true
+		}
+		if len(seen) != 20 {
+			t.Errorf("received %d distinct message(s), want 20", len(seen))
+		}
+	})
+
+	t.Run("retries a failing handler", func(t *testing.T) {
+		pub, sub := newBus(t, testRetry)
+
+		var mu sync.Mutex
+		attempts := 0
+		c := newCollector()
+		subscribe(t, sub, "users", "mailer", func(ctx context.Context, msg *Message) error {
+			mu.Lock()
+			attempts++
+			n := attempts
+			mu.Unlock()
+			if n <= testRetry.MaxRetries {
+				return errors.New("temporary failure")
+			}
+			return c.handle(ctx, msg)
+		})
+		dead := newCollector()
+		subscribe(t, sub, DeadLetterTopic("users"), "inspect", dead.handle)
+
+		publish(t, pub, "users", testMessages(1)...)
+		c.wait(t, 1)
+
+		time.Sleep(50 * time.Millisecond)
+		if got := dead.wait(t, 0); len(got) != 0 {
+			t.Errorf("dead-lettered %d message(s) that succeeded on retry", len(got))
+		}
+	})
+
+	for name, handler := range map[string]Handler{
+		"error": func(context.Context, *Message) error { return errors.New("boom") },
+		"panic": func(context.Context, *Message) error { panic("boom") },
+	} {
+		t.Run("dead-letters a message after its retries on "+name, func(t *testing.T) {
+			pub, sub := newBus(t, testRetry)
+
+			var mu sync.Mutex
+			attempts := make(map[string]int)
+			c := newCollector()
+			subscribe(t, sub, "users", "mailer", func(ctx context.Context, msg *Message) error {
+				mu.Lock()
+				attempts[msg.ID]++
+				mu.Unlock()
+				if msg.ID == "poison" {
+					return handler(ctx, msg)
+				}
+				return c.handle(ctx, msg)
+			})
+			dead := newCollector()
+			subscribe(t, sub, DeadLetterTopic("users"), "inspect", dead.handle)
+
+			publish(t, pub, "users",
+				&Message{ID: "poison", Payload: []byte(`{}`), Headers: map[string]string{"source": "test"}},
+				&Message{ID: "healthy", Payload: []byte(`{}`)},
+			)
+
+			// The poison message does not block the ones after it
+			if got := c.wait(t, 1); got[0].ID != "healthy" {
+				t.Errorf("handled %q, want healthy", got[0].ID)
+			}
+
+			got := dead.wait(t, 1)[0]
+			if got.ID != "poison" || string(got.Payload) != `{}` {
+				t.Errorf("dead letter = %q %s, want the poison message", got.ID, got.Payload)
+			}
+			if got.Headers["x-dead-letter-error"] == "" {
+				t.Error("dead letter has no x-dead-letter-error header")
+			}
+			if got.Headers["x-dead-letter-group"] != "mailer" {
+				t.Errorf("x-dead-letter-group = %q, want mailer", got.Headers["x-dead-letter-group"])
+			}
+			if got.Headers["source"] != "test" {
+				t.Errorf("dead letter lost the original headers: %v", got.Headers)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if attempts["poison"] != testRetry.MaxRetries+1 {
+				t.Errorf("poison message attempted %d time(s), want %d", attempts["poison"], testRetry.MaxRetries+1)
+			}
+		})
+	}
+
+	t.Run("returns when the context is cancelled", func(t *testing.T) {
+		_, sub := newBus(t, testRetry)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- sub.Subscribe(ctx, "users", "mailer", func(context.Context, *Message) error { return nil })
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Subscribe() error = %v, want nil", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Subscribe() did not return after cancel")
+		}
+	})
+}
+
+func TestMemoryContract(t *testing.T) {
+	testBusContract(t, func(t *testing.T, retry RetryPolicy) (Publisher, Subscriber) {
+		bus := NewMemory(retry)
+		return bus, bus
+	})
+}
+
+func TestMemoryDeadLetters(t *testing.T) {
+	bus := NewMemory(RetryPolicy{})
+	done := newCollector()
+	subscribe(t, bus, "users", "mailer", func(ctx context.Context, msg *Message) error {
+		defer func() { _ = done.handle(ctx, msg) }()
+		return errors.New("boom")
+	})
+
+	publish(t, bus, "users", testMessages(1)...)
+	done.wait(t, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bus.DeadLetters("users")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	dead := bus.DeadLetters("users")
+	if len(dead) != 1 || dead[0].ID != "msg-0" {
+		t.Fatalf("DeadLetters() = %v, want msg-0", dead)
+	}
+	if got := bus.Messages("users"); len(got) != 1 {
+		t.Errorf("Messages() returned %d message(s), want 1", len(got))
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+	for _, tt := range tests {
+		got := policy.Backoff(tt.retry)
+		// Jitter takes off up to 20%
+		if got > tt.want || got < tt.want*4/5 {
+			t.Errorf("Backoff(%d) = %s, want within 20%% below %s", tt.retry, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"go-user-service/internal/pkg/tracing"
+)
+
+// Memory is an in-process Publisher and Subscriber for tests and local runs.
+// Consumer groups share an offset per topic, so competing subscribers in the
+// same group each receive a message once, like Redis Streams.
+type Memory struct {
+	retry RetryPolicy
+
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+	seq    int64
+}
+
+type memoryTopic struct {
+	messages []*Message
+	offsets  map[string]int
+	notify   chan struct{}
+}
+
+// NewMemory creates a new in-memory event bus
+func NewMemory(retry RetryPolicy) *Memory {
+	return &Memory{
+		retry:  retry,
+		topics: make(map[string]*memoryTopic),
+	}
+}
+
+func (m *Memory) topic(name string) *memoryTopic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &memoryTopic{offsets: make(map[string]int), notify: make(chan struct{})}
+		m.topics[name] = t
+	}
+	return t
+}
+
+// Publish implements Publisher
+func (m *Memory) Publish(ctx context.Context, topic string, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	stored := *msg
+	stored.Topic = topic
+	stored.StreamID = strconv.FormatInt(m.seq, 10)
+	if stored.Headers == nil {
+		stored.Headers = tracing.Inject(ctx)
+	}
+	msg.Topic, msg.StreamID = stored.Topic, stored.StreamID
+
+	t := m.topic(topic)
+	t.messages = append(t.messages, &stored)
+
+	// Wake up every waiting subscriber
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return nil
+}
+
+// Subscribe implements Subscriber
+func (m *Memory) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	for {
+		m.mu.Lock()
+		t := m.topic(topic)
+		offset := t.offsets[group]
+		if offset >= len(t.messages) {
+			notify := t.notify
+			m.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-notify:
+				continue
+			}
+		}
+		t.offsets[group] = offset + 1
+		msg := *t.messages[offset]
+		m.mu.Unlock()
+
+		msg.Deliveries = 1
+		spanCtx, span := tracing.StartJob(ctx, msg.Headers, "consume "+topic)
+		err := deliver(spanCtx, m.retry, handler, &msg)
+		tracing.RecordError(span, err)
+		span.End()
+
+		if err != nil && ctx.Err() == nil {
+			headers := make(map[string]string, len(msg.Headers)+1)
+			for k, v := range msg.Headers {
+				headers[k] = v
+			}
+			headers["x-dead-letter-error"] = err.Error()
+			headers["x-dead-letter-group"] = group
+			_ = m.Publish(ctx, DeadLetterTopic(topic), &Message{ID: msg.ID, Payload: msg.Payload, Headers: headers})
+		}
+	}
+}
+
+// Messages returns a copy of every message published to topic
+func (m *Memory) Messages(topic string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.topics[topic]
+	if !ok {
+		return nil
+	}
+	messages := make([]Message, 0, len(t.messages))
+	for _, msg := range t.messages {
+		messages = append(messages, *msg)
+	}
+	return messages
+}
+
+// DeadLetters returns the messages dead-lettered from topic
+func (m *Memory) DeadLetters(topic string) []Message {
+	return m.Messages(DeadLetterTopic(topic))
+}
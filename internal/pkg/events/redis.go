@@ -0,0 +1,298 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Stream entry field names
+const (
+	fieldID      = "id"
+	fieldPayload = "payload"
+	fieldHeaders = "headers"
+)
+
+// StreamOptions configures the Redis Streams bus
+type StreamOptions struct {
+	// Consumer names this process inside every consumer group
+	Consumer string
+	// MaxLen caps each stream length (approximate trimming), 0 keeps everything
+	MaxLen int64
+	// BatchSize is the number of entries read per XREADGROUP call
+	BatchSize int64
+	// Concurrency is the number of entries handled in parallel per subscription
+	Concurrency int
+	// Block is how long XREADGROUP waits for new entries
+	Block time.Duration
+	// ClaimInterval is how often stuck pending entries are reclaimed
+	ClaimInterval time.Duration
+	// ClaimMinIdle is how long an entry must be pending before another consumer may claim it
+	ClaimMinIdle time.Duration
+	// Retry bounds in-process retries and redeliveries before dead-lettering
+	Retry RetryPolicy
+}
+
+// DefaultStreamOptions returns options suitable for most consumers
+func DefaultStreamOptions() StreamOptions {
+	hostname, _ := os.Hostname()
+	return StreamOptions{
+		Consumer:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		MaxLen:        100000,
+		BatchSize:     10,
+		Concurrency:   4,
+		Block:         2 * time.Second,
+		ClaimInterval: 30 * time.Second,
+		ClaimMinIdle:  5 * time.Minute,
+		Retry:         DefaultRetryPolicy,
+	}
+}
+
+// RedisStreams is a Publisher and Subscriber backed by Redis Streams consumer groups
+type RedisStreams struct {
+	client *redis.Client
+	opts   StreamOptions
+	logger *logger.Logger
+}
+
+// NewRedisStreams creates a new Redis Streams event bus
+func NewRedisStreams(client *redis.Client, opts StreamOptions, logger *logger.Logger) *RedisStreams {
+	return &RedisStreams{client: client, opts: opts, logger: logger}
+}
+
+// Publish implements Publisher
+func (r *RedisStreams) Publish(ctx context.Context, topic string, msg *Message) error {
+	headers := msg.Headers
+	if headers == nil {
+		headers = tracing.Inject(ctx)
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: topic,
+		Values: map[string]interface{}{
+			fieldID:      msg.ID,
+			fieldPayload: msg.Payload,
+			fieldHeaders: encoded,
+		},
+	}
+	if r.opts.MaxLen > 0 {
+		args.MaxLen = r.opts.MaxLen
+		args.Approx = true
+	}
+
+	id, err := r.client.XAdd(ctx, args).Result()
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	msg.Topic = topic
+	msg.StreamID = id
+	return nil
+}
+
+// Subscribe implements Subscriber
+func (r *RedisStreams) Subscribe(ctx context.Context, topic, group string, handler Handler) error {
+	// Start from the beginning so a new group also sees events published before it existed
+	err := r.client.XGroupCreateMkStream(ctx, topic, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on %s: %w", group, topic, err)
+	}
+
+	r.logger.WithFields(logger.Fields{
+		"topic":    topic,
+		"group":    group,
+		"consumer": r.opts.Consumer,
+	}).Info("Subscribed to event stream")
+
+	sem := make(chan struct{}, max(r.opts.Concurrency, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	dispatch := func(messages []redis.XMessage, reclaimed bool) {
+		for _, xmsg := range messages {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(xmsg redis.XMessage) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				r.process(ctx, topic, group, handler, xmsg, reclaimed)
+			}(xmsg)
+		}
+	}
+
+	lastClaim := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= r.opts.ClaimInterval {
+			lastClaim = time.Now()
+			dispatch(r.reclaim(ctx, topic, group), true)
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: r.opts.Consumer,
+			Streams:  []string{topic, ">"},
+			Count:    r.opts.BatchSize,
+			Block:    r.opts.Block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			r.logger.LogError(err, "event_stream_read", map[string]interface{}{"topic": topic, "group": group})
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			dispatch(stream.Messages, false)
+		}
+	}
+
+	return nil
+}
+
+// reclaim takes over entries left pending by crashed or stuck consumers
+func (r *RedisStreams) reclaim(ctx context.Context, topic, group string) []redis.XMessage {
+	var claimed []redis.XMessage
+	start := "0-0"
+	for {
+		messages, next, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   topic,
+			Group:    group,
+			Consumer: r.opts.Consumer,
+			MinIdle:  r.opts.ClaimMinIdle,
+			Start:    start,
+			Count:    r.opts.BatchSize,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.LogError(err, "event_stream_reclaim", map[string]interface{}{"topic": topic, "group": group})
+			}
+			return claimed
+		}
+
+		claimed = append(claimed, messages...)
+		if next == "0-0" || len(messages) == 0 {
+			return claimed
+		}
+		start = next
+	}
+}
+
+func (r *RedisStreams) process(ctx context.Context, topic, group string, handler Handler, xmsg redis.XMessage, reclaimed bool) {
+	msg := decodeMessage(topic, xmsg)
+	msg.Deliveries = 1
+
+	if reclaimed {
+		deliveries, err := r.deliveries(ctx, topic, group, xmsg.ID)
+		if err == nil {
+			msg.Deliveries = deliveries
+		}
+		// An entry that keeps getting stuck is most likely crashing its consumer
+		if msg.Deliveries > int64(r.opts.Retry.MaxRetries)+1 {
+			r.deadLetter(ctx, topic, group, msg, fmt.Errorf("exceeded %d deliveries", r.opts.Retry.MaxRetries+1))
+			return
+		}
+	}
+
+	spanCtx, span := tracing.StartJob(ctx, msg.Headers, "consume "+topic,
+		attribute.String("messaging.system", "redis"),
+		attribute.String("messaging.destination.name", topic),
+		attribute.String("messaging.consumer.group.name", group),
+		attribute.String("messaging.message.id", msg.ID),
+	)
+	err := deliver(spanCtx, r.opts.Retry, handler, msg)
+	tracing.RecordError(span, err)
+	span.End()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: leave the entry pending so it is reclaimed later
+			return
+		}
+		r.deadLetter(ctx, topic, group, msg, err)
+		return
+	}
+
+	if err := r.client.XAck(ctx, topic, group, xmsg.ID).Err(); err != nil {
+		r.logger.LogError(err, "event_stream_ack", map[string]interface{}{"topic": topic, "stream_id": xmsg.ID})
+	}
+}
+
+func (r *RedisStreams) deliveries(ctx context.Context, topic, group, id string) (int64, error) {
+	pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	return pending[0].RetryCount, nil
+}
+
+// deadLetter copies the entry to the dead-letter stream, then acknowledges it
+func (r *RedisStreams) deadLetter(ctx context.Context, topic, group string, msg *Message, cause error) {
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers["x-dead-letter-error"] = cause.Error()
+	headers["x-dead-letter-group"] = group
+	headers["x-original-stream-id"] = msg.StreamID
+	headers["x-failed-at"] = time.Now().UTC().Format(time.RFC3339)
+
+	dead := &Message{ID: msg.ID, Payload: msg.Payload, Headers: headers}
+	if err := r.Publish(ctx, DeadLetterTopic(topic), dead); err != nil {
+		// Not acknowledged, the entry stays pending and will be retried
+		r.logger.LogError(err, "event_stream_dead_letter", map[string]interface{}{"topic": topic, "stream_id": msg.StreamID})
+		return
+	}
+	if err := r.client.XAck(ctx, topic, group, msg.StreamID).Err(); err != nil {
+		r.logger.LogError(err, "event_stream_ack", map[string]interface{}{"topic": topic, "stream_id": msg.StreamID})
+	}
+
+	r.logger.WithFields(logger.Fields{
+		"topic":      topic,
+		"group":      group,
+		"message_id": msg.ID,
+		"error":      cause.Error(),
+	}).Warn("Event moved to dead-letter stream")
+}
+
+func decodeMessage(topic string, xmsg redis.XMessage) *Message {
+	msg := &Message{Topic: topic, StreamID: xmsg.ID}
+	if v, ok := xmsg.Values[fieldID].(string); ok {
+		msg.ID = v
+	}
+	if v, ok := xmsg.Values[fieldPayload].(string); ok {
+		msg.Payload = []byte(v)
+	}
+	if v, ok := xmsg.Values[fieldHeaders].(string); ok && v != "" {
+		_ = json.Unmarshal([]byte(v), &msg.Headers)
+	}
+	return msg
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+)
+
+func newTestStreams(t *testing.T, opts StreamOptions) (*RedisStreams, *redis.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisStreams(client, opts, logger.New("error", "test")), client
+}
+
+func testStreamOptions(retry RetryPolicy) StreamOptions {
+	opts := DefaultStreamOptions()
+	opts.Consumer = "test"
+	opts.Block = 20 * time.Millisecond
+	opts.Retry = retry
+	return opts
+}
+
+func TestRedisStreamsContract(t *testing.T) {
+	testBusContract(t, func(t *testing.T, retry RetryPolicy) (Publisher, Subscriber) {
+		bus, _ := newTestStreams(t, testStreamOptions(retry))
+		return bus, bus
+	})
+}
+
+// crash leaves the entries of topic pending on a consumer that never acknowledges them
+func crash(t *testing.T, client *redis.Client, topic, group string) []redis.XMessage {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := client.XGroupCreateMkStream(ctx, topic, group, "0").Err(); err != nil {
+		t.Fatalf("XGROUP CREATE error = %v", err)
+	}
+	streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: "crashed",
+		Streams:  []string{topic, ">"},
+	}).Result()
+	if err != nil {
+		t.Fatalf("XREADGROUP error = %v", err)
+	}
+	return streams[0].Messages
+}
+
+func pendingCount(t *testing.T, client *redis.Client, topic, group string) int64 {
+	t.Helper()
+
+	pending, err := client.XPending(context.Background(), topic, group).Result()
+	if err != nil {
+		t.Fatalf("XPENDING error = %v", err)
+	}
+	return pending.Count
+}
+
+func TestRedisStreamsReclaim(t *testing.T) {
+	opts := testStreamOptions(testRetry)
+	opts.ClaimInterval = 10 * time.Millisecond
+	opts.ClaimMinIdle = 50 * time.Millisecond
+	bus, client := newTestStreams(t, opts)
+
+	publish(t, bus, "users", testMessages(2)...)
+	crash(t, client, "users", "mailer")
+
+	c := newCollector()
+	subscribe(t, bus, "users", "mailer", c.handle)
+
+	got := c.wait(t, 2)
+	for _, msg := range got {
+		if msg.Deliveries != 2 {
+			t.Errorf("message %s deliveries = %d, want 2", msg.ID, msg.Deliveries)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pendingCount(t, client, "users", "mailer") > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := pendingCount(t, client, "users", "mailer"); n != 0 {
+		t.Errorf("%d entries still pending after reclaim", n)
+	}
+}
+
+func TestRedisStreamsReclaimWaitsForMinIdle(t *testing.T) {
+	opts := testStreamOptions(testRetry)
+	opts.ClaimInterval = 10 * time.Millisecond
+	opts.ClaimMinIdle = time.Hour
+	bus, client := newTestStreams(t, opts)
+
+	publish(t, bus, "users", testMessages(1)...)
+	crash(t, client, "users", "mailer")
+
+	c := newCollector()
+	subscribe(t, bus, "users", "mailer", c.handle)
+
+	time.Sleep(100 * time.Millisecond)
+	if got := c.wait(t, 0); len(got) != 0 {
+		t.Errorf("reclaimed %d entry(ies) that were not idle long enough", len(got))
+	}
+	if n := pendingCount(t, client, "users", "mailer"); n != 1 {
+		t.Errorf("pending = %d, want the crashed consumer's entry", n)
+	}
+}
+
+func TestRedisStreamsDeadLettersStuckEntries(t *testing.T) {
+	opts := testStreamOptions(testRetry)
+	opts.ClaimInterval = 10 * time.Millisecond
+	opts.ClaimMinIdle = 10 * time.Millisecond
+	bus, client := newTestStreams(t, opts)
+
+	publish(t, bus, "users", testMessages(1)...)
+	entries := crash(t, client, "users", "mailer")
+
+	// Pretend the entry already crashed its consumer on every allowed delivery
+	err := client.Do(context.Background(), "XCLAIM", "users", "mailer", "crashed", 0, entries[0].ID,
+		"RETRYCOUNT", testRetry.MaxRetries+1).Err()
+	if err != nil {
+		t.Fatalf("XCLAIM error = %v", err)
+	}
+	time.Sleep(opts.ClaimMinIdle)
+
+	handled := newCollector()
+	subscribe(t, bus, "users", "mailer", handled.handle)
+	dead := newCollector()
+	subscribe(t, bus, DeadLetterTopic("users"), "inspect", dead.handle)
+
+	got := dead.wait(t, 1)[0]
+	if got.ID != "msg-0" {
+		t.Errorf("dead letter ID = %q, want msg-0", got.ID)
+	}
+	if got.Headers["x-original-stream-id"] != entries[0].ID {
+		t.Errorf("x-original-stream-id = %q, want %q", got.Headers["x-original-stream-id"], entries[0].ID)
+	}
+	if got.Headers["x-dead-letter-group"] != "mailer" || got.Headers["x-failed-at"] == "" {
+		t.Errorf("dead letter headers = %v", got.Headers)
+	}
+
+	if n := len(handled.wait(t, 0)); n != 0 {
+		t.Errorf("handler ran %d time(s) for an entry over its delivery limit", n)
+	}
+	if n := pendingCount(t, client, "users", "mailer"); n != 0 {
+		t.Errorf("%d entries still pending after dead-lettering", n)
+	}
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+)
+
+// Status represents the state of the service or a dependency
+type Status string
+
+const (
+	StatusUp           Status = "up"
+	StatusDown         Status = "down"
+	StatusStarting     Status = "starting"
+	StatusShuttingDown Status = "shutting_down"
+)
+
+// Probe checks a single dependency and returns an error when it is unavailable
+type Probe func(ctx context.Context) error
+
+// Info describes the running build
+type Info struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// CheckResult holds the outcome of one dependency probe
+type CheckResult struct {
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is returned by the liveness and readiness endpoints
+type Report struct {
+	Status    Status                 `json:"status"`
+	App       Info                   `json:"app"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+type namedProbe struct {
+	name  string
+	probe Probe
+}
+
+// Checker runs dependency probes and tracks startup and shutdown state
+type Checker struct {
+	info         Info
+	timeout      time.Duration
+	logger       *logger.Logger
+	probes       []namedProbe
+	starting     atomic.Bool
+	shuttingDown atomic.Bool
+
+	mu         sync.Mutex
+	lastStatus map[string]Status
+}
+
+// NewChecker creates a new health checker
+func NewChecker(info Info, timeout time.Duration, logger *logger.Logger) *Checker {
+	return &Checker{
+		info:       info,
+		timeout:    timeout,
+		logger:     logger,
+		lastStatus: make(map[string]Status),
+	}
+}
+
+// Register adds a dependency probe used by readiness
+func (c *Checker) Register(name string, probe Probe) {
+	c.probes = append(c.probes, namedProbe{name: name, probe: probe})
+}
+
+// MarkStarting makes readiness fail until MarkStarted, for a process that
+// serves before its dependencies are up
+func (c *Checker) MarkStarting() {
+	c.starting.Store(true)
+}
+
+// MarkStarted ends the startup phase begun by MarkStarting
+func (c *Checker) MarkStarted() {
+	c.starting.Store(false)
+}
+
+// IsStarting reports whether the startup phase begun by MarkStarting is still running
+func (c *Checker) IsStarting() bool {
+	return c.starting.Load()
+}
+
+// MarkShuttingDown makes readiness fail so load balancers drain the instance
+func (c *Checker) MarkShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// IsShuttingDown reports whether MarkShuttingDown has been called
+func (c *Checker) IsShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Liveness reports that the process is running without touching dependencies
+func (c *Checker) Liveness() Report {
+	return Report{
+		Status:    StatusUp,
+		App:       c.info,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// Readiness probes every registered dependency concurrently
+func (c *Checker) Readiness(ctx context.Context) Report {
+	report := Report{
+		Status:    StatusUp,
+		App:       c.info,
+		Checks:    make(map[string]CheckResult, len(c.probes)),
+		Timestamp: time.Now().UTC(),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, p := range c.probes {
+		wg.Add(1)
+		go func(p namedProbe) {
+			defer wg.Done()
+			result := c.run(ctx, p)
+
+			mu.Lock()
+			report.Checks[p.name] = result
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	if c.IsStarting() {
+		report.Status = StatusStarting
+	}
+	if c.IsShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, p namedProbe) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.probe(ctx)
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	c.record(p.name, result.Status, err)
+	return result
+}
+
+// record logs failures and recoveries without flooding the log on every probe
+func (c *Checker) record(name string, status Status, err error) {
+	c.mu.Lock()
+	previous, seen := c.lastStatus[name]
+	c.lastStatus[name] = status
+	c.mu.Unlock()
+
+	if c.logger == nil {
+		return
+	}
+	if status == StatusDown || !seen || previous != status {
+		c.logger.LogHealthCheck(name, status == StatusUp, err)
+	}
+}
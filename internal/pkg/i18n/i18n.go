@@ -0,0 +1,213 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+)
+
+//go:embed locales/*.json
+var catalogFS embed.FS
+
+// SourceLocale is the language messages are written in inside the code
+const SourceLocale = "en"
+
+// supportedLocales lists the plural rules and formats of every locale with a catalog file
+var supportedLocales = map[string]func() locales.Translator{
+	"en": en.New,
+	"id": id.New,
+}
+
+// catalogFile is the layout of locales/<locale>.json
+type catalogFile struct {
+	// Codes holds the generic message of each error code
+	Codes map[string]string `json:"codes"`
+	// Messages translates specific messages, keyed by their source text
+	Messages map[string]string `json:"messages"`
+}
+
+// Keys are typed so catalog entries never collide with validation tags
+// registered on the same translator
+type (
+	codeKey    string
+	messageKey string
+)
+
+// Catalog holds the translators of every supported locale
+type Catalog struct {
+	uni           *ut.UniversalTranslator
+	defaultLocale string
+	locales       []string
+}
+
+// New loads the embedded message catalogs. Requests that match no
+// supported locale are answered in defaultLocale.
+func New(defaultLocale string) (*Catalog, error) {
+	newDefault, ok := supportedLocales[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported default locale %q", defaultLocale)
+	}
+
+	c := &Catalog{
+		uni:           ut.New(newDefault()),
+		defaultLocale: defaultLocale,
+	}
+
+	files, err := fs.Glob(catalogFS, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		newLocale, ok := supportedLocales[locale]
+		if !ok {
+			return nil, fmt.Errorf("no locale rules registered for catalog %s", file)
+		}
+		if err := c.uni.AddTranslator(newLocale(), true); err != nil {
+			return nil, err
+		}
+		if err := c.load(locale, file); err != nil {
+			return nil, err
+		}
+		c.locales = append(c.locales, locale)
+	}
+	sort.Strings(c.locales)
+
+	return c, nil
+}
+
+func (c *Catalog) load(locale, file string) error {
+	data, err := catalogFS.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var catalog catalogFile
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	trans, _ := c.uni.GetTranslator(locale)
+	for code, text := range catalog.Codes {
+		if err := trans.Add(codeKey(code), text, true); err != nil {
+			return fmt.Errorf("%s: code %s: %w", file, code, err)
+		}
+	}
+	for source, text := range catalog.Messages {
+		if err := trans.Add(messageKey(source), text, true); err != nil {
+			return fmt.Errorf("%s: message %q: %w", file, source, err)
+		}
+	}
+	return nil
+}
+
+// Locales returns the supported locales, sorted
+func (c *Catalog) Locales() []string {
+	return c.locales
+}
+
+// DefaultLocale returns the locale used when negotiation finds no match
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+// IsSupported reports whether locale has a catalog
+func (c *Catalog) IsSupported(locale string) bool {
+	_, ok := c.uni.GetTranslator(locale)
+	return ok && locale != ""
+}
+
+// Translator returns the translator of locale, falling back to the default locale
+func (c *Catalog) Translator(locale string) ut.Translator {
+	if trans, ok := c.uni.GetTranslator(locale); ok {
+		return trans
+	}
+	trans, _ := c.uni.GetTranslator(c.defaultLocale)
+	return trans
+}
+
+// Negotiate picks the best supported locale of an Accept-Language header,
+// e.g. "id-ID,id;q=0.9,en;q=0.8". Region subtags fall back to their language.
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: strings.ToLower(tag), q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, cand := range candidates {
+		tag := strings.ReplaceAll(cand.tag, "-", "_")
+		if c.IsSupported(tag) {
+			return tag
+		}
+		if base, _, found := strings.Cut(tag, "_"); found && c.IsSupported(base) {
+			return base
+		}
+	}
+	return c.defaultLocale
+}
+
+// Error translates an error message. The specific translation of message is
+// preferred, then the generic message of code; the source locale keeps message as is.
+func Error(trans ut.Translator, code, message string) string {
+	if trans.Locale() == SourceLocale {
+		return message
+	}
+	if text, err := trans.T(messageKey(message)); err == nil {
+		return text
+	}
+	if text, err := trans.T(codeKey(code)); err == nil {
+		return text
+	}
+	return message
+}
+
+// Title returns the generic message of code, or fallback when the catalog has none
+func Title(trans ut.Translator, code, fallback string) string {
+	if text, err := trans.T(codeKey(code)); err == nil {
+		return text
+	}
+	return fallback
+}
+
+type translatorKey struct{}
+
+// NewContext returns a context carrying the translator of the negotiated locale
+func NewContext(ctx context.Context, trans ut.Translator) context.Context {
+	return context.WithValue(ctx, translatorKey{}, trans)
+}
+
+// FromContext returns the translator stored by NewContext
+func FromContext(ctx context.Context) (ut.Translator, bool) {
+	trans, ok := ctx.Value(translatorKey{}).(ut.Translator)
+	return trans, ok
+}
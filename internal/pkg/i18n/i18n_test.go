@@ -0,0 +1,117 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+)
+
+func newCatalog(t *testing.T, defaultLocale string) *Catalog {
+	t.Helper()
+	catalog, err := New(defaultLocale)
+	if err != nil {
+		t.Fatalf("New(%q) error = %v", defaultLocale, err)
+	}
+	return catalog
+}
+
+func TestNew(t *testing.T) {
+	catalog := newCatalog(t, "id")
+	if got := catalog.Locales(); len(got) != 2 || got[0] != "en" || got[1] != "id" {
+		t.Errorf("Locales() = %v, want [en id]", got)
+	}
+	if got := catalog.DefaultLocale(); got != "id" {
+		t.Errorf("DefaultLocale() = %q, want id", got)
+	}
+
+	if _, err := New("fr"); err == nil {
+		t.Error("New(fr) succeeded, want an unsupported locale error")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultLocale  string
+		acceptLanguage string
+		want           string
+	}{
+		{"no header", "en", "", "en"},
+		{"no header with another default", "id", "", "id"},
+		{"exact match", "en", "id", "id"},
+		{"region falls back to its language", "en", "id-ID", "id"},
+		{"case insensitive", "en", "ID-id", "id"},
+		{"highest quality wins", "en", "en;q=0.5, id;q=0.9", "id"},
+		{"order breaks ties", "en", "id, en", "id"},
+		{"skips unsupported", "en", "fr-FR, de;q=0.9, id;q=0.8", "id"},
+		{"nothing supported", "id", "fr-FR, de", "id"},
+		{"wildcard", "id", "*", "id"},
+		{"refused language", "en", "id;q=0, en;q=0.1", "en"},
+		{"malformed quality", "en", "id;q=abc, en", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := newCatalog(t, tt.defaultLocale)
+			if got := catalog.Negotiate(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	catalog := newCatalog(t, "id")
+	if got := catalog.Translator("en").Locale(); got != "en" {
+		t.Errorf("Translator(en) = %s, want en", got)
+	}
+	if got := catalog.Translator("fr").Locale(); got != "id" {
+		t.Errorf("Translator(fr) = %s, want the default id", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	catalog := newCatalog(t, "en")
+
+	tests := []struct {
+		name    string
+		locale  string
+		code    string
+		message string
+		want    string
+	}{
+		{"source locale keeps the message", "en", "NOT_FOUND", "User not found", "User not found"},
+		{"specific translation", "id", "NOT_FOUND", "User not found", "Pengguna tidak ditemukan"},
+		{"falls back to the code", "id", "NOT_FOUND", "Webhook not found anywhere", "Data yang diminta tidak ditemukan"},
+		{"unknown code keeps the message", "id", "NO_SUCH_CODE", "Something odd", "Something odd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Error(catalog.Translator(tt.locale), tt.code, tt.message)
+			if got != tt.want {
+				t.Errorf("Error(%s, %s, %q) = %q, want %q", tt.locale, tt.code, tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitle(t *testing.T) {
+	catalog := newCatalog(t, "en")
+	if got := Title(catalog.Translator("id"), "NOT_FOUND", "Not Found"); got != "Data yang diminta tidak ditemukan" {
+		t.Errorf("Title(id, NOT_FOUND) = %q", got)
+	}
+	if got := Title(catalog.Translator("id"), "NO_SUCH_CODE", "Not Found"); got != "Not Found" {
+		t.Errorf("Title(id, NO_SUCH_CODE) = %q, want the fallback", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext() found a translator in an empty context")
+	}
+	trans := newCatalog(t, "en").Translator("id")
+	got, ok := FromContext(NewContext(context.Background(), trans))
+	if !ok || got.Locale() != "id" {
+		t.Errorf("FromContext() = %v, %v, want the id translator", got, ok)
+	}
+}
@@ -0,0 +1,93 @@
+// Package lease provides Redis locks that expire unless renewed, shared by
+// the cron scheduler and the user cache
+package lease
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// renewScript extends the lease only while it is still held by the same token
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only while it is still held by the same token
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lease is a Redis lock that expires unless renewed, so a crashed holder
+// cannot keep it forever
+type Lease struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// New creates a lease on key, it is not acquired yet
+func New(client *redis.Client, key string, ttl time.Duration) *Lease {
+	return &Lease{
+		client: client,
+		key:    key,
+		token:  uuid.NewString(),
+		ttl:    ttl,
+	}
+}
+
+// Acquire takes the lease, returning false when someone else holds it
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	return l.client.SetNX(ctx, l.key, l.token, l.ttl).Result()
+}
+
+// Renew extends the lease, returning false when it was lost
+func (l *Lease) Renew(ctx context.Context) (bool, error) {
+	n, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Release gives the lease up if it is still held
+func (l *Lease) Release(ctx context.Context) error {
+	err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+// KeepAlive renews the lease at a third of its TTL until ctx is done.
+// lost is called once if the lease cannot be renewed.
+func (l *Lease) KeepAlive(ctx context.Context, lost func()) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := l.Renew(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || !ok {
+				lost()
+				return
+			}
+		}
+	}
+}
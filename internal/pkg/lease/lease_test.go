@@ -0,0 +1,101 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+)
+
+func newClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestLease(t *testing.T) {
+	mr, client := newClient(t)
+	ctx := context.Background()
+
+	first := New(client, "lease:test", time.Minute)
+	second := New(client, "lease:test", time.Minute)
+
+	if ok, err := first.Acquire(ctx); !ok || err != nil {
+		t.Fatalf("first Acquire() = %v, %v, want the lease", ok, err)
+	}
+	if ok, err := second.Acquire(ctx); ok || err != nil {
+		t.Fatalf("second Acquire() = %v, %v, want it held by the first", ok, err)
+	}
+
+	// Only the holder renews or releases the lease
+	if ok, err := second.Renew(ctx); ok || err != nil {
+		t.Errorf("Renew() by another holder = %v, %v, want false", ok, err)
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Errorf("Release() by another holder error = %v", err)
+	}
+	if !mr.Exists("lease:test") {
+		t.Fatal("another holder released the lease")
+	}
+
+	mr.FastForward(50 * time.Second)
+	if ok, err := first.Renew(ctx); !ok || err != nil {
+		t.Fatalf("Renew() = %v, %v, want the lease extended", ok, err)
+	}
+	if ttl := mr.TTL("lease:test"); ttl != time.Minute {
+		t.Errorf("TTL after Renew() = %s, want %s", ttl, time.Minute)
+	}
+
+	if err := first.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if ok, err := second.Acquire(ctx); !ok || err != nil {
+		t.Errorf("Acquire() after Release() = %v, %v, want the lease", ok, err)
+	}
+}
+
+func TestLeaseExpires(t *testing.T) {
+	mr, client := newClient(t)
+	ctx := context.Background()
+
+	crashed := New(client, "lease:test", time.Minute)
+	if ok, _ := crashed.Acquire(ctx); !ok {
+		t.Fatal("Acquire() did not take a free lease")
+	}
+	mr.FastForward(time.Minute)
+
+	next := New(client, "lease:test", time.Minute)
+	if ok, err := next.Acquire(ctx); !ok || err != nil {
+		t.Fatalf("Acquire() after expiry = %v, %v, want the lease", ok, err)
+	}
+	if ok, _ := crashed.Renew(ctx); ok {
+		t.Error("Renew() by the expired holder took the lease back")
+	}
+}
+
+func TestKeepAliveReportsLoss(t *testing.T) {
+	mr, client := newClient(t)
+
+	held := New(client, "lease:test", 30*time.Millisecond)
+	if ok, _ := held.Acquire(context.Background()); !ok {
+		t.Fatal("Acquire() did not take a free lease")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lost := make(chan struct{})
+	go held.KeepAlive(ctx, func() { close(lost) })
+
+	// Another instance took the lease over, e.g. after a long pause
+	mr.Set("lease:test", "other")
+
+	select {
+	case <-lost:
+	case <-ctx.Done():
+		t.Fatal("KeepAlive() did not report the lost lease")
+	}
+}
@@ -1,10 +1,12 @@
 package logger
 
 import (
+	"context"
 	"os"
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger wraps logrus logger with additional functionality
@@ -53,6 +55,9 @@ func New(level, env string) *Logger {
 	// Add caller information
 	log.SetReportCaller(true)
 
+	// Add trace correlation fields
+	log.AddHook(traceHook{})
+
 	return &Logger{Logger: log}
 }
 
@@ -71,9 +76,29 @@ func (l *Logger) WithError(err error) *logrus.Entry {
 	return l.Logger.WithError(err)
 }
 
-// WithContext adds context to logger
-func (l *Logger) WithContext(ctx interface{}) *logrus.Entry {
-	return l.Logger.WithField("context", ctx)
+// WithContext adds context to logger so entries carry its trace_id and span_id
+func (l *Logger) WithContext(ctx context.Context) *logrus.Entry {
+	return l.Logger.WithContext(ctx)
+}
+
+// traceHook adds trace_id and span_id to entries logged with a span context
+type traceHook struct{}
+
+func (traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (traceHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(entry.Context)
+	if !sc.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = sc.TraceID().String()
+	entry.Data["span_id"] = sc.SpanID().String()
+	return nil
 }
 
 // HTTP request logging helpers
@@ -232,7 +257,7 @@ func (l *Logger) LogStartup(service, version string, config map[string]interface
 	// Add config fields (be careful not to log secrets)
 	for k, v := range config {
 		// Skip sensitive fields
-		if !isSensitiveField(k) {
+		if !IsSensitiveField(k) {
 			fields[k] = v
 		}
 	}
@@ -248,8 +273,8 @@ func (l *Logger) LogShutdown(service string, reason string) {
 	}).Info("Service shutting down")
 }
 
-// Helper function to check if field is sensitive
-func isSensitiveField(field string) bool {
+// IsSensitiveField reports whether a field name looks like it holds a secret
+func IsSensitiveField(field string) bool {
 	sensitiveFields := []string{
 		"password", "secret", "key", "token", "auth",
 		"credential", "private", "sensitive",
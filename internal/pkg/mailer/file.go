@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileSender writes messages into a Maildir (tmp/, new/, cur/) so they can
+// be opened with any mail client during local development
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender creates a new file sender, creating the Maildir when missing
+func NewFileSender(dir string) (*FileSender, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create maildir %s: %w", dir, err)
+		}
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send implements Sender
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	// Write to tmp/ then rename into new/ so readers never see partial files
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%s.%s.eml", time.Now().UnixNano(), uuid.NewString(), hostname)
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to deliver message: %w", err)
+	}
+	return nil
+}
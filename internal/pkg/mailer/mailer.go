@@ -0,0 +1,111 @@
+// Package mailer renders localised email templates and delivers them
+// through a pluggable Sender.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+
+	"go-user-service/internal/pkg/config"
+)
+
+// JobType is the worker job that renders and sends an Email
+const JobType = "email.send"
+
+// ErrInvalidRecipient is returned when the recipient is not a valid address
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// Message is a rendered email ready to be delivered
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Sender delivers a rendered message
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Email is a request to send a template, it is the payload of the email.send job
+type Email struct {
+	To       string                 `json:"to"`
+	Template string                 `json:"template"`
+	Locale   string                 `json:"locale,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// Mailer renders emails from templates and hands them to a Sender
+type Mailer struct {
+	renderer *Renderer
+	sender   Sender
+	from     string
+	appName  string
+}
+
+// New creates a new mailer
+func New(renderer *Renderer, sender Sender, from, appName string) *Mailer {
+	return &Mailer{
+		renderer: renderer,
+		sender:   sender,
+		from:     from,
+		appName:  appName,
+	}
+}
+
+// Send renders the email template and delivers it
+func (m *Mailer) Send(ctx context.Context, email Email) error {
+	if _, err := mail.ParseAddress(email.To); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidRecipient, email.To, err)
+	}
+
+	data := map[string]interface{}{"AppName": m.appName}
+	for key, value := range email.Data {
+		data[key] = value
+	}
+
+	msg, err := m.renderer.Render(email.Template, email.Locale, data)
+	if err != nil {
+		return err
+	}
+	msg.From = m.from
+	msg.To = []string{email.To}
+
+	return m.sender.Send(ctx, msg)
+}
+
+// IsPermanent reports whether retrying the send cannot succeed: an unknown
+// template, an invalid recipient or a 5xx SMTP reply
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrUnknownTemplate) || errors.Is(err, ErrInvalidRecipient) {
+		return true
+	}
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500
+}
+
+// NewSender creates the sender selected by MAIL_DRIVER
+func NewSender(cfg config.MailConfig) (Sender, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPSender(SMTPOptions{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			TLS:      cfg.TLS,
+		}), nil
+	case "file":
+		return NewFileSender(cfg.Dir)
+	case "memory":
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
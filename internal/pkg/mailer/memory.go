@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps sent messages in memory, intended for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemorySender creates a new in-memory sender
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send implements Sender
+func (s *MemorySender) Send(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (s *MemorySender) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.messages...)
+}
+
+// Reset forgets every sent message
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
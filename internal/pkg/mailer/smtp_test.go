@@ -0,0 +1,320 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStub is a minimal in-process SMTP server that records what it receives
+type smtpStub struct {
+	listener net.Listener
+	// reject maps a command verb (MAIL, RCPT, DATA) to the reply that refuses it
+	reject map[string]string
+	// auth advertises AUTH PLAIN when set
+	auth bool
+
+	mu       sync.Mutex
+	commands []string
+	plain    string
+	from     string
+	to       []string
+	data     string
+}
+
+// newSMTPStub starts the stub after applying configure, so the server
+// goroutines only read the configuration
+func newSMTPStub(t *testing.T, configure ...func(s *smtpStub)) *smtpStub {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpStub{listener: listener, reject: make(map[string]string)}
+	for _, fn := range configure {
+		fn(s)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) options() SMTPOptions {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPOptions{Host: host, Port: port, TLS: TLSNone, Timeout: 5 * time.Second}
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(line string) { _ = text.PrintfLine("%s", line) }
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.mu.Unlock()
+
+		if rejection, ok := s.reject[verb]; ok {
+			reply(rejection)
+			continue
+		}
+
+		switch verb {
+		case "EHLO":
+			if s.auth {
+				reply("250-stub")
+				reply("250 AUTH PLAIN")
+			} else {
+				reply("250 stub")
+			}
+		case "AUTH":
+			fields := strings.Fields(line)
+			decoded, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			s.mu.Lock()
+			s.plain = string(decoded)
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.TrimPrefix(line, "MAIL FROM:")
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.to = append(s.to, strings.TrimPrefix(line, "RCPT TO:"))
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		From:    "App <no-reply@example.com>",
+		To:      []string{"Budi <budi@example.com>"},
+		Subject: "Selamat datang, Budi!",
+		Text:    "Hi Budi\n",
+		HTML:    "<p>Hi Budi</p>",
+		Headers: map[string]string{"content-language": "id"},
+	}
+}
+
+func TestSMTPSenderDelivers(t *testing.T) {
+	stub := newSMTPStub(t)
+
+	if err := NewSMTPSender(stub.options()).Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	if got := strings.Join(stub.commands, " "); got != "EHLO MAIL RCPT DATA QUIT" {
+		t.Errorf("commands = %s", got)
+	}
+	if !strings.Contains(stub.from, "<no-reply@example.com>") {
+		t.Errorf("MAIL FROM = %q, want the bare sender address", stub.from)
+	}
+	if len(stub.to) != 1 || !strings.Contains(stub.to[0], "<budi@example.com>") {
+		t.Errorf("RCPT TO = %v, want the bare recipient address", stub.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(stub.data))
+	if err != nil {
+		t.Fatalf("delivered message does not parse: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Selamat datang, Budi!" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if got := msg.Header.Get("Content-Language"); got != "id" {
+		t.Errorf("Content-Language = %q, want id", got)
+	}
+	if msg.Header.Get("Message-Id") == "" || msg.Header.Get("Date") == "" {
+		t.Error("Message-ID or Date header missing")
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type does not parse: %v", err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Hi Budi\n"},
+		{"text/html; charset=utf-8", "<p>Hi Budi</p>"},
+	}
+	for _, w := range want {
+		part, err := parts.NextRawPart()
+		if err != nil {
+			t.Fatalf("missing %s part: %v", w.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != w.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, w.contentType)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		// Quoted-printable turns line breaks into CRLF
+		if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != w.body {
+			t.Errorf("%s body = %q, want %q", w.contentType, got, w.body)
+		}
+	}
+}
+
+func TestSMTPSenderAuth(t *testing.T) {
+	stub := newSMTPStub(t, func(s *smtpStub) { s.auth = true })
+
+	opts := stub.options()
+	opts.Username, opts.Password = "mailer", "s3cret"
+	if err := NewSMTPSender(opts).Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.plain != "\x00mailer\x00s3cret" {
+		t.Errorf("AUTH PLAIN credentials = %q", stub.plain)
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		reject    map[string]string
+		tls       string
+		permanent bool
+	}{
+		{
+			name:      "mailbox unavailable",
+			reject:    map[string]string{"RCPT": "550 no such user"},
+			permanent: true,
+		},
+		{
+			name:   "greylisted",
+			reject: map[string]string{"RCPT": "451 try again later"},
+		},
+		{
+			name:      "message rejected",
+			reject:    map[string]string{"DATA": "554 transaction failed"},
+			permanent: true,
+		},
+		{
+			name: "starttls not offered",
+			tls:  TLSStartTLS,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSMTPStub(t, func(s *smtpStub) { s.reject = tt.reject })
+
+			opts := stub.options()
+			if tt.tls != "" {
+				opts.TLS = tt.tls
+			}
+			err := NewSMTPSender(opts).Send(context.Background(), testMessage())
+			if err == nil {
+				t.Fatal("Send() succeeded, want an error")
+			}
+			if got := IsPermanent(err); got != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, got, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestSMTPSenderUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	err = NewSMTPSender(SMTPOptions{Host: host, Port: port, TLS: TLSNone}).Send(context.Background(), testMessage())
+	if err == nil {
+		t.Fatal("Send() to a closed port succeeded")
+	}
+	if IsPermanent(err) {
+		t.Errorf("IsPermanent(%v) = true, want a connection failure to be retried", err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("error %v does not wrap the dial error", err)
+	}
+}
+
+func TestSMTPSenderHonoursContext(t *testing.T) {
+	// A server that accepts but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = bufio.NewReader(conn).ReadString('\n')
+				conn.Close()
+			}()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = NewSMTPSender(SMTPOptions{Host: host, Port: port, TLS: TLSNone}).Send(ctx, testMessage())
+	if err == nil {
+		t.Fatal("Send() to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send() took %s, want it bounded by the context deadline", elapsed)
+	}
+}
@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// ErrUnknownTemplate is returned when no locale provides the requested template
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// fallbackLocale is used when neither the requested nor the default locale has a template
+const fallbackLocale = "en"
+
+// Renderer turns templates into messages. Each template lives in
+// templates/<locale>/<name>.html and <name>.txt; the text template also
+// defines the "subject" block. HTML templates are wrapped by templates/layout.html.
+type Renderer struct {
+	defaultLocale string
+	html          map[string]*htmltemplate.Template
+	text          map[string]*texttemplate.Template
+}
+
+// NewRenderer parses every embedded template
+func NewRenderer(defaultLocale string) (*Renderer, error) {
+	r := &Renderer{
+		defaultLocale: defaultLocale,
+		html:          make(map[string]*htmltemplate.Template),
+		text:          make(map[string]*texttemplate.Template),
+	}
+
+	layout, err := htmltemplate.ParseFS(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email layout: %w", err)
+	}
+
+	err = fs.WalkDir(templateFS, "templates", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		locale := path.Base(path.Dir(file))
+		if locale == "templates" {
+			return nil
+		}
+		name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+		key := locale + "/" + name
+
+		switch path.Ext(file) {
+		case ".html":
+			tmpl, err := layout.Clone()
+			if err == nil {
+				tmpl, err = tmpl.ParseFS(templateFS, file)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			r.html[key] = tmpl
+		case ".txt":
+			tmpl, err := texttemplate.ParseFS(templateFS, file)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			r.text[key] = tmpl
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Render renders the named template in the closest available locale
+func (r *Renderer) Render(name, locale string, data map[string]interface{}) (*Message, error) {
+	resolved, ok := r.resolve(name, locale)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	key := resolved + "/" + name
+
+	var subject, text, html bytes.Buffer
+	textTmpl := r.text[key]
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", key, err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", key, err)
+	}
+	if tmpl, ok := r.html[key]; ok {
+		if err := tmpl.ExecuteTemplate(&html, "layout", data); err != nil {
+			return nil, fmt.Errorf("failed to render %s html: %w", key, err)
+		}
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+		Headers: map[string]string{"Content-Language": resolved},
+	}, nil
+}
+
+// resolve picks the locale to render: the requested one, its base language
+// (id-ID -> id), the default locale, then English
+func (r *Renderer) resolve(name, locale string) (string, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, r.defaultLocale, fallbackLocale)
+
+	for _, candidate := range candidates {
+		if _, ok := r.text[candidate+"/"+name]; ok && candidate != "" {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,197 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestMailer(t *testing.T, defaultLocale string) (*Mailer, *MemorySender) {
+	t.Helper()
+
+	renderer, err := NewRenderer(defaultLocale)
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	sender := NewMemorySender()
+	return New(renderer, sender, "Go User Service <no-reply@example.com>", "Go User Service"), sender
+}
+
+func TestMailerRendersLocalizedTemplates(t *testing.T) {
+	data := map[string]interface{}{"Username": "budi", "Email": "budi@example.com"}
+
+	tests := []struct {
+		name          string
+		locale        string
+		defaultLocale string
+		wantLocale    string
+		wantSubject   string
+		wantText      string
+		wantHTML      string
+	}{
+		{
+			name:          "english",
+			locale:        "en",
+			defaultLocale: "en",
+			wantLocale:    "en",
+			wantSubject:   "Welcome to Go User Service, budi!",
+			wantText:      "Your Go User Service account has been created with the email address budi@example.com.",
+			wantHTML:      "<strong>budi@example.com</strong>",
+		},
+		{
+			name:          "indonesian",
+			locale:        "id",
+			defaultLocale: "en",
+			wantLocale:    "id",
+			wantSubject:   "Selamat datang di Go User Service, budi!",
+			wantText:      "Akun Go User Service Anda telah dibuat dengan alamat email budi@example.com.",
+			wantHTML:      "Akun Go User Service Anda telah dibuat",
+		},
+		{
+			name:          "region falls back to the base language",
+			locale:        "id_ID",
+			defaultLocale: "en",
+			wantLocale:    "id",
+			wantSubject:   "Selamat datang di Go User Service, budi!",
+		},
+		{
+			name:          "unknown locale uses the default locale",
+			locale:        "fr",
+			defaultLocale: "id",
+			wantLocale:    "id",
+			wantSubject:   "Selamat datang di Go User Service, budi!",
+		},
+		{
+			name:          "missing default locale falls back to english",
+			locale:        "",
+			defaultLocale: "fr",
+			wantLocale:    "en",
+			wantSubject:   "Welcome to Go User Service, budi!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, sender := newTestMailer(t, tt.defaultLocale)
+
+			err := m.Send(context.Background(), Email{
+				To:       "budi@example.com",
+				Template: "welcome",
+				Locale:   tt.locale,
+				Data:     data,
+			})
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			sent := sender.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d message(s), want 1", len(sent))
+			}
+			msg := sent[0]
+
+			if msg.From != "Go User Service <no-reply@example.com>" || len(msg.To) != 1 || msg.To[0] != "budi@example.com" {
+				t.Errorf("From = %q, To = %v", msg.From, msg.To)
+			}
+			if got := msg.Headers["Content-Language"]; got != tt.wantLocale {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLocale)
+			}
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.wantSubject)
+			}
+			if !strings.Contains(msg.Text, tt.wantText) {
+				t.Errorf("Text = %q, want it to contain %q", msg.Text, tt.wantText)
+			}
+			if strings.Contains(msg.Text, "<") {
+				t.Errorf("Text contains markup: %q", msg.Text)
+			}
+			if !strings.HasPrefix(msg.HTML, "<!DOCTYPE html>") || !strings.Contains(msg.HTML, tt.wantHTML) {
+				t.Errorf("HTML = %q, want the layout around %q", msg.HTML, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func TestMailerEscapesHTML(t *testing.T) {
+	m, sender := newTestMailer(t, "en")
+
+	err := m.Send(context.Background(), Email{
+		To:       "budi@example.com",
+		Template: "welcome",
+		Data: map[string]interface{}{
+			"Username": `<script>alert("x")</script> & more`,
+			"Email":    "budi@example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	msg := sender.Messages()[0]
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Errorf("HTML contains unescaped data: %q", msg.HTML)
+	}
+	if !strings.Contains(msg.HTML, "&lt;script&gt;") {
+		t.Errorf("HTML = %q, want the escaped username", msg.HTML)
+	}
+	// The plain-text part is not HTML and keeps the data as is
+	if !strings.Contains(msg.Text, `<script>alert("x")</script> & more`) {
+		t.Errorf("Text = %q, want the raw username", msg.Text)
+	}
+}
+
+func TestMailerRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		email Email
+		want  error
+	}{
+		{
+			name:  "unknown template",
+			email: Email{To: "budi@example.com", Template: "missing"},
+			want:  ErrUnknownTemplate,
+		},
+		{
+			name:  "invalid recipient",
+			email: Email{To: "not an address", Template: "welcome"},
+			want:  ErrInvalidRecipient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, sender := newTestMailer(t, "en")
+
+			err := m.Send(context.Background(), tt.email)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Send() error = %v, want %v", err, tt.want)
+			}
+			if !IsPermanent(err) {
+				t.Errorf("IsPermanent(%v) = false, want true", err)
+			}
+			if n := len(sender.Messages()); n != 0 {
+				t.Errorf("sent %d message(s), want none", n)
+			}
+		})
+	}
+}
+
+func TestRendererParsesEveryLocale(t *testing.T) {
+	renderer, err := NewRenderer("en")
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	// Every template exists in both formats in every locale
+	for key := range renderer.text {
+		if _, ok := renderer.html[key]; !ok {
+			t.Errorf("%s has a text template but no html template", key)
+		}
+	}
+	for key := range renderer.html {
+		if _, ok := renderer.text[key]; !ok {
+			t.Errorf("%s has an html template but no text template", key)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/pkg/secrets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminKey requires the "X-Admin-Key" header to match the current value of key,
+// so a rotated key applies without a restart. An empty key rejects every
+// request, so admin routes are off until configured.
+func AdminKey(key *secrets.Value) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given, key := c.GetHeader("X-Admin-Key"), key.Get()
+		if key == "" || subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+			response.Error(c, errors.New(errors.ErrCodeForbidden, "Admin access required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
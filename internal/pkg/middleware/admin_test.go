@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-user-service/internal/pkg/secrets"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	next := "key-1"
+	key := secrets.NewValue("server.admin_api_key", "key-1", func(context.Context) (string, error) { return next, nil })
+
+	router := gin.New()
+	router.GET("/admin", AdminKey(key), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	status := func(given string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if given != "" {
+			req.Header.Set("X-Admin-Key", given)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if got := status("key-1"); got != http.StatusNoContent {
+		t.Errorf("current key: status = %d, want 204", got)
+	}
+	if got := status("wrong"); got != http.StatusForbidden {
+		t.Errorf("wrong key: status = %d, want 403", got)
+	}
+	if got := status(""); got != http.StatusForbidden {
+		t.Errorf("no key: status = %d, want 403", got)
+	}
+
+	// A rotated key applies to the next request
+	next = "key-2"
+	if _, err := key.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := status("key-1"); got != http.StatusForbidden {
+		t.Errorf("rotated out key: status = %d, want 403", got)
+	}
+	if got := status("key-2"); got != http.StatusNoContent {
+		t.Errorf("rotated key: status = %d, want 204", got)
+	}
+
+	// An unset key keeps the routes disabled
+	router = gin.New()
+	router.GET("/admin", AdminKey(secrets.NewValue("server.admin_api_key", "", nil)), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	if got := status(""); got != http.StatusForbidden {
+		t.Errorf("unset key: status = %d, want 403", got)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strings"
+
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth requires a valid "Authorization: Bearer <token>" header and stores
+// the user ID in the request context
+func Auth(keys auth.Keys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			response.Error(c, errors.New(errors.ErrCodeUnauthorized, "Missing bearer token"))
+			c.Abort()
+			return
+		}
+
+		userID, err := auth.Verify(keys, token)
+		if err != nil {
+			response.Error(c, errors.Wrap(err, errors.ErrCodeUnauthorized, "Invalid or expired token"))
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than maxBytes with 413. Bodies
+// without a Content-Length fail to read past the limit instead.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			response.Error(c, errors.New(errors.ErrCodePayloadTooLarge, "Request body is too large").
+				WithMetadata("max_bytes", maxBytes))
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
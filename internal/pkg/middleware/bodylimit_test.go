@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(BodyLimit(64))
+	// Handlers wrap every bind error as a validation error
+	router.POST("/users", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid registration request"))
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	large := `{"name":"` + strings.Repeat("a", 128) + `"}`
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		wantStatus int
+		wantCode   errors.ErrorCode
+	}{
+		{"within the limit", `{"name":"budi"}`, false, http.StatusCreated, ""},
+		{"declared too large", large, false, http.StatusRequestEntityTooLarge, errors.ErrCodePayloadTooLarge},
+		{"streamed past the limit", large, true, http.StatusRequestEntityTooLarge, errors.ErrCodePayloadTooLarge},
+		{"malformed", `{"name":`, true, http.StatusBadRequest, errors.ErrCodeValidation},
+		{"invalid", `{}`, false, http.StatusBadRequest, errors.ErrCodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				// Hide the length so only the reader enforces the limit
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/users", body)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var resp response.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Error == nil || resp.Error.Code != string(tt.wantCode) {
+				t.Errorf("error = %+v, want %s", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
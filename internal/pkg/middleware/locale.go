@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale negotiates the response language from the Accept-Language header
+// and stores its translator in the request context
+func Locale(catalog *i18n.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		setLocale(c, catalog, catalog.Negotiate(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// LocaleLookup returns the preferred locale of a user, empty when none is set
+type LocaleLookup func(ctx context.Context, userID uint) (string, error)
+
+// UserLocale lets the authenticated user's saved locale override Accept-Language.
+// It must run after Auth; lookup failures keep the negotiated locale.
+func UserLocale(catalog *i18n.Catalog, lookup LocaleLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, ok := auth.UserID(c.Request.Context()); ok {
+			locale, err := lookup(c.Request.Context(), userID)
+			if err == nil && catalog.IsSupported(locale) {
+				setLocale(c, catalog, locale)
+			}
+		}
+		c.Next()
+	}
+}
+
+func setLocale(c *gin.Context, catalog *i18n.Catalog, locale string) {
+	trans := catalog.Translator(locale)
+	c.Set("locale", trans.Locale())
+	c.Header("Content-Language", trans.Locale())
+	c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), trans))
+}
@@ -3,12 +3,9 @@ package middleware
 import (
 	"fmt"
 	"log"
-	"runtime/debug"
 	"time"
 
-	"go-user-service/internal/pkg/errors"
 	"go-user-service/internal/pkg/logger"
-	"go-user-service/internal/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,7 +27,7 @@ func LoggerMiddleware(l logger.Logger) gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
-		l.Info(fmt.Sprintf("%s %s %d %v %s",
+		l.WithContext(c.Request.Context()).Info(fmt.Sprintf("%s %s %d %v %s",
 			method,
 			path,
 			statusCode,
@@ -40,16 +37,6 @@ func LoggerMiddleware(l logger.Logger) gin.HandlerFunc {
 	}
 }
 
-// Recovery middleware untuk menangkap panic
-func Recovery() gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		log.Printf("Panic recovered: %v\n%s", recovered, debug.Stack())
-
-		appErr := errors.New(errors.ErrCodeInternal, "Internal server error")
-		response.Error(c, appErr)
-	})
-}
-
 // ErrorLogger middleware untuk log error
 func ErrorLogger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-user-service/internal/pkg/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrimaryForWrites pins the queries of requests that may write to the
+// primary database, so their reads see the writes. Safe methods may read
+// from replicas.
+func PrimaryForWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			c.Request = c.Request.WithContext(database.WithPrimary(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorReporter receives every recovered panic, e.g. to forward it to an error tracking service
+type ErrorReporter func(c *gin.Context, err error, stack []byte)
+
+// panicCounter counts recovered panics, it is a no-op until a meter provider is installed
+var panicCounter, _ = otel.Meter(tracing.InstrumentationName).Int64Counter("http.server.panics",
+	metric.WithDescription("Panics recovered from HTTP handlers"))
+
+// Recovery turns handler panics into a logged, counted and reported INTERNAL_ERROR
+// response. Writes to clients that already went away are only logged as a warning.
+// reporter may be nil.
+func Recovery(l *logger.Logger, reporter ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+
+			if isBrokenConnection(err) {
+				l.WithContext(c.Request.Context()).WithError(err).Warn("Client closed the connection")
+				c.Error(err)
+				c.Abort()
+				return
+			}
+
+			stack := debug.Stack()
+			tracing.RecordError(trace.SpanFromContext(c.Request.Context()), err)
+			l.LogError(fmt.Errorf("panic: %w", err), "http_recovery", map[string]interface{}{
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"route":      c.FullPath(),
+				"client_ip":  c.ClientIP(),
+				"request_id": c.GetString("request_id"),
+				"trace_id":   tracing.TraceID(c.Request.Context()),
+				"stack":      string(stack),
+			})
+			panicCounter.Add(c.Request.Context(), 1, metric.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", c.FullPath()),
+			))
+			if reporter != nil {
+				reporter(c, err, stack)
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			response.Error(c, errors.Wrap(err, errors.ErrCodeInternal, "Internal server error"))
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
+
+// isBrokenConnection reports whether err comes from writing to a client that went away
+func isBrokenConnection(err error) bool {
+	if errors.Is(err, http.ErrAbortHandler) {
+		return true
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
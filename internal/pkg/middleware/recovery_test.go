@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"go-user-service/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// reported is one call of the ErrorReporter
+type reported struct {
+	err   error
+	stack []byte
+}
+
+func brokenPipe(errno syscall.Errno) error {
+	return &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", errno)}
+}
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name         string
+		handler      gin.HandlerFunc
+		wantStatus   int
+		wantBody     string
+		wantError    bool
+		wantReported error
+		// wantReport expects an error log and a reporter call, wantWarn only a warning
+		wantReport bool
+		wantWarn   bool
+	}{
+		{
+			name:       "no panic",
+			handler:    func(c *gin.Context) { c.String(http.StatusOK, "ok") },
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:         "panic with an error",
+			handler:      func(c *gin.Context) { panic(errBoom) },
+			wantStatus:   http.StatusInternalServerError,
+			wantError:    true,
+			wantReported: errBoom,
+			wantReport:   true,
+		},
+		{
+			name:       "panic with a value",
+			handler:    func(c *gin.Context) { panic("index out of range") },
+			wantStatus: http.StatusInternalServerError,
+			wantError:  true,
+			wantReport: true,
+		},
+		{
+			name: "panic after writing",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "partial")
+				panic(errBoom)
+			},
+			wantStatus:   http.StatusOK,
+			wantBody:     "partial",
+			wantReported: errBoom,
+			wantReport:   true,
+		},
+		{
+			name:       "broken pipe",
+			handler:    func(c *gin.Context) { panic(brokenPipe(syscall.EPIPE)) },
+			wantStatus: http.StatusOK,
+			wantWarn:   true,
+		},
+		{
+			name:       "connection reset",
+			handler:    func(c *gin.Context) { panic(brokenPipe(syscall.ECONNRESET)) },
+			wantStatus: http.StatusOK,
+			wantWarn:   true,
+		},
+		{
+			name:       "aborted handler",
+			handler:    func(c *gin.Context) { panic(http.ErrAbortHandler) },
+			wantStatus: http.StatusOK,
+			wantWarn:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := logger.New("debug", "test")
+			l.SetOutput(io.Discard)
+			hook := logtest.NewLocal(l.Logger)
+
+			var reports []reported
+			reporter := func(c *gin.Context, err error, stack []byte) {
+				reports = append(reports, reported{err: err, stack: stack})
+			}
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) { c.Set("request_id", "req-1") })
+			router.Use(Recovery(l, reporter))
+			router.GET("/users/:id", tt.handler)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantError {
+				var body struct {
+					Error struct {
+						Code      string `json:"code"`
+						Message   string `json:"message"`
+						RequestID string `json:"request_id"`
+					} `json:"error"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("response does not parse: %v: %s", err, rec.Body)
+				}
+				if body.Error.Code != "INTERNAL_ERROR" || body.Error.RequestID != "req-1" {
+					t.Errorf("error = %+v, want INTERNAL_ERROR for req-1", body.Error)
+				}
+				if strings.Contains(rec.Body.String(), "boom") || strings.Contains(rec.Body.String(), "index out of range") {
+					t.Errorf("body = %s, the panic value must not leak", rec.Body)
+				}
+			} else if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+
+			wantReports := 0
+			if tt.wantReport {
+				wantReports = 1
+			}
+			if len(reports) != wantReports {
+				t.Fatalf("reporter called %d times, want %d", len(reports), wantReports)
+			}
+
+			entry := hook.LastEntry()
+			switch {
+			case tt.wantReport:
+				if tt.wantReported != nil && !errors.Is(reports[0].err, tt.wantReported) {
+					t.Errorf("reported %v, want %v", reports[0].err, tt.wantReported)
+				}
+				if !strings.Contains(string(reports[0].stack), "recovery_test.go") {
+					t.Errorf("reported stack does not reach the handler:\n%s", reports[0].stack)
+				}
+				if entry == nil || entry.Level != logrus.ErrorLevel {
+					t.Fatalf("last log entry = %v, want an error", entry)
+				}
+				if entry.Data["request_id"] != "req-1" || entry.Data["route"] != "/users/:id" || entry.Data["stack"] == "" {
+					t.Errorf("log fields = %v, want the request id, route and stack", entry.Data)
+				}
+			case tt.wantWarn:
+				if entry == nil || entry.Level != logrus.WarnLevel {
+					t.Errorf("last log entry = %v, want a warning", entry)
+				}
+			case entry != nil:
+				t.Errorf("logged %q, want nothing", entry.Message)
+			}
+		})
+	}
+}
+
+func TestRecoveryWithoutReporter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := logger.New("debug", "test")
+	l.SetOutput(io.Discard)
+
+	router := gin.New()
+	router.Use(Recovery(l, nil))
+	router.GET("/", func(c *gin.Context) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+}
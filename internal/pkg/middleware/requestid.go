@@ -1,13 +1,16 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
 
-// RequestID middleware untuk tracking request
+// RequestID middleware untuk tracking request, memakai X-Request-ID dari client bila ada
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestId := c.GetHeader("X-Request-ID")
-		if requestId == "" {
-			requestId = generateRequestID()
+		if requestId == "" || len(requestId) > 128 {
+			requestId = uuid.NewString()
 		}
 
 		c.Header("X-Request-ID", requestId)
@@ -15,17 +18,3 @@ func RequestID() gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-func generateRequestID() string {
-	// Simple implementation, bisa pakai UUID library
-	return "req-" + randomString(10)
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[len(charset)%len(charset)]
-	}
-	return string(b)
-}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/health"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireStarted answers 503 until the startup phase of checker ends, so a
+// process serving before its dependencies are up only answers health checks
+func RequireStarted(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checker.IsStarting() {
+			response.Error(c, errors.New(errors.ErrCodeUnavailable, "Service is starting"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/health"
+	"go-user-service/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireStarted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := health.NewChecker(health.Info{Name: "test"}, time.Second, logger.New("panic", "test"))
+	router := gin.New()
+	router.GET("/health/live", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.Group("/api/v1", RequireStarted(checker)).GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	checker.MarkStarting()
+	if got := serve("/api/v1/users"); got != http.StatusServiceUnavailable {
+		t.Errorf("while starting, /api/v1/users = %d, want 503", got)
+	}
+	if got := serve("/health/live"); got != http.StatusOK {
+		t.Errorf("while starting, /health/live = %d, want 200", got)
+	}
+
+	checker.MarkStarted()
+	if got := serve("/api/v1/users"); got != http.StatusOK {
+		t.Errorf("once started, /api/v1/users = %d, want 200", got)
+	}
+}
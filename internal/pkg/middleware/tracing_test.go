@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  int
+		wantError   bool
+		wantRemote  bool
+	}{
+		{
+			name:        "continues the caller trace",
+			path:        "/users/42",
+			traceparent: "00-" + traceID + "-" + spanID + "-01",
+			wantName:    "GET /users/:id",
+			wantStatus:  http.StatusOK,
+			wantRemote:  true,
+		},
+		{
+			name:       "starts a new trace",
+			path:       "/users/42",
+			wantName:   "GET /users/:id",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "ignores a malformed traceparent",
+			path:        "/users/42",
+			traceparent: "00-not-a-trace-01",
+			wantName:    "GET /users/:id",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:       "marks server errors",
+			path:       "/fail",
+			wantName:   "GET /fail",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  true,
+		},
+		{
+			name:       "names unmatched routes by path",
+			path:       "/missing",
+			wantName:   "GET /missing",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := useRecorder(t)
+
+			var handlerSpan trace.SpanContext
+			router := gin.New()
+			router.Use(Tracing())
+			router.GET("/users/:id", func(c *gin.Context) {
+				handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+			router.GET("/fail", func(c *gin.Context) {
+				c.Status(http.StatusInternalServerError)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if span.Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", span.Name(), tt.wantName)
+			}
+			if span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("kind = %v, want server", span.SpanKind())
+			}
+			if handlerSpan.IsValid() && handlerSpan.SpanID() != span.SpanContext().SpanID() {
+				t.Error("the handler context does not carry the server span")
+			}
+
+			if span.Parent().IsValid() != tt.wantRemote {
+				t.Errorf("has parent = %v, want %v", span.Parent().IsValid(), tt.wantRemote)
+			}
+			if tt.wantRemote {
+				if got := span.SpanContext().TraceID().String(); got != traceID {
+					t.Errorf("trace ID = %s, want %s", got, traceID)
+				}
+				if got := span.Parent().SpanID().String(); got != spanID {
+					t.Errorf("parent span ID = %s, want %s", got, spanID)
+				}
+				if !span.Parent().IsRemote() {
+					t.Error("parent is not marked remote")
+				}
+			}
+
+			var status int64
+			for _, kv := range span.Attributes() {
+				if kv.Key == "http.response.status_code" {
+					status = kv.Value.AsInt64()
+				}
+			}
+			if status != int64(tt.wantStatus) {
+				t.Errorf("http.response.status_code = %d, want %d", status, tt.wantStatus)
+			}
+			if gotError := span.Status().Code == codes.Error; gotError != tt.wantError {
+				t.Errorf("error status = %v, want %v", gotError, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Headers holds message metadata such as the W3C trace context
+type Headers map[string]string
+
+// Value implements driver.Valuer
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan implements sql.Scanner
+func (h *Headers) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported headers type %T", value)
+	}
+	return json.Unmarshal(data, h)
+}
+
+// Message is a row of the outbox table
+type Message struct {
+	ID            string          `gorm:"primaryKey"`
+	AggregateType string          `gorm:"not null"`
+	AggregateID   string          `gorm:"not null"`
+	EventType     string          `gorm:"not null"`
+	Payload       json.RawMessage `gorm:"type:jsonb;not null"`
+	Headers       Headers         `gorm:"type:jsonb;not null"`
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+	Attempts      int
+	LastError     string
+}
+
+// TableName implements gorm.Tabler
+func (Message) TableName() string {
+	return "outbox"
+}
+
+// Event rebuilds the domain event stored in the message
+func (m *Message) Event() domain.Event {
+	return domain.Event{
+		ID:            m.ID,
+		Type:          domain.EventType(m.EventType),
+		AggregateType: m.AggregateType,
+		AggregateID:   m.AggregateID,
+		OccurredAt:    m.CreatedAt,
+		Payload:       m.Payload,
+	}
+}
+
+// Store writes events to the outbox inside the caller's transaction
+type Store struct{}
+
+// NewStore creates a new outbox store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Add records events in the same transaction as the state change that produced them.
+// The trace context of ctx is kept in the headers so consumers can continue the trace.
+func (s *Store) Add(ctx context.Context, tx *gorm.DB, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	headers := Headers(tracing.Inject(ctx))
+	messages := make([]Message, 0, len(events))
+	for _, event := range events {
+		messages = append(messages, Message{
+			ID:            event.ID,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			EventType:     string(event.Type),
+			Payload:       event.Payload,
+			Headers:       headers,
+			CreatedAt:     event.OccurredAt,
+		})
+	}
+
+	if err := tx.WithContext(ctx).Create(&messages).Error; err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
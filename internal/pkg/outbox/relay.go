@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/events"
+	"go-user-service/internal/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Publisher delivers outbox messages to the message bus
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// Relay moves pending outbox rows to the message bus and marks them delivered.
+// Delivery is at-least-once: a crash between publishing and committing
+// republishes the row, so consumers must be idempotent on the event ID.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	logger    *logger.Logger
+	batchSize int
+	interval  time.Duration
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(db *gorm.DB, publisher Publisher, logger *logger.Logger, batchSize int, interval time.Duration) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		logger:    logger,
+		batchSize: batchSize,
+		interval:  interval,
+	}
+}
+
+// Run relays batches until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("Starting outbox relay...")
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches back to back, then wait for the next tick
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.LogError(err, "outbox_relay", nil)
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes one batch of pending messages and returns how many were delivered.
+// Rows are locked with SKIP LOCKED so several relays can run side by side.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	delivered := 0
+
+	err := database.WithTransaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var messages []Message
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL").
+			Order("created_at").
+			Limit(r.batchSize).
+			Find(&messages).Error
+		if err != nil {
+			return fmt.Errorf("failed to load outbox batch: %w", err)
+		}
+
+		for i := range messages {
+			msg := &messages[i]
+
+			if err := r.publisher.Publish(ctx, msg); err != nil {
+				r.logger.LogError(err, "outbox_publish", map[string]interface{}{
+					"message_id": msg.ID,
+					"event_type": msg.EventType,
+				})
+
+				// Keep the row pending, stop so ordering is preserved
+				return tx.Model(msg).Updates(map[string]interface{}{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": err.Error(),
+				}).Error
+			}
+
+			now := time.Now().UTC()
+			err := tx.Model(msg).Updates(map[string]interface{}{
+				"delivered_at": now,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"last_error":   "",
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to mark outbox message %s delivered: %w", msg.ID, err)
+			}
+			delivered++
+		}
+		return nil
+	})
+
+	return delivered, err
+}
+
+// BusPublisher publishes outbox messages as domain events on the event bus
+type BusPublisher struct {
+	bus events.Publisher
+}
+
+// NewBusPublisher creates a publisher that writes to the per-aggregate topic
+func NewBusPublisher(bus events.Publisher) *BusPublisher {
+	return &BusPublisher{bus: bus}
+}
+
+// Publish implements Publisher
+func (p *BusPublisher) Publish(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(msg.Event())
+	if err != nil {
+		return err
+	}
+	return p.bus.Publish(ctx, domain.Topic(msg.AggregateType), &events.Message{
+		ID:      msg.ID,
+		Payload: payload,
+		Headers: msg.Headers,
+	})
+}
@@ -0,0 +1,47 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorDoc documents an error code in the GET /errors catalog
+type ErrorDoc struct {
+	errors.Definition
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+func errorDoc(c *gin.Context, def errors.Definition) ErrorDoc {
+	title := http.StatusText(def.Status)
+	if trans, ok := i18n.FromContext(c.Request.Context()); ok {
+		title = i18n.Title(trans, string(def.Code), title)
+	}
+	return ErrorDoc{Definition: def, Type: ProblemType(def.Code), Title: title}
+}
+
+// ErrorCatalog lists every error code the API returns
+func ErrorCatalog(c *gin.Context) {
+	defs := errors.Definitions()
+	docs := make([]ErrorDoc, 0, len(defs))
+	for _, def := range defs {
+		docs = append(docs, errorDoc(c, def))
+	}
+	OK(c, docs)
+}
+
+// ErrorType documents the error code of a problem type URI, e.g. /errors/rate-limited
+func ErrorType(c *gin.Context) {
+	code := errors.ErrorCode(strings.ToUpper(strings.ReplaceAll(c.Param("type"), "-", "_")))
+	def, ok := errors.Lookup(code)
+	if !ok {
+		NotFound(c, "Error type not found")
+		return
+	}
+	OK(c, errorDoc(c, def))
+}
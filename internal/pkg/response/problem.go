@@ -0,0 +1,101 @@
+package response
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Error formats
+const (
+	// FormatEnvelope renders errors inside the Response envelope
+	FormatEnvelope = "envelope"
+	// FormatProblem renders errors as RFC 7807 problem details
+	FormatProblem = "problem"
+)
+
+// Config selects how errors are rendered
+type Config struct {
+	// ErrorFormat is used unless the client asks for application/problem+json
+	ErrorFormat string
+	// ProblemTypeBaseURL prefixes the problem type URI of each error code
+	ProblemTypeBaseURL string
+}
+
+var config = Config{
+	ErrorFormat:        FormatEnvelope,
+	ProblemTypeBaseURL: "/api/v1/errors/",
+}
+
+// Configure sets the error format, it must be called before serving requests
+func Configure(cfg Config) {
+	if cfg.ErrorFormat != "" {
+		config.ErrorFormat = cfg.ErrorFormat
+	}
+	if cfg.ProblemTypeBaseURL != "" {
+		config.ProblemTypeBaseURL = cfg.ProblemTypeBaseURL
+	}
+}
+
+// Problem is an RFC 7807 problem details object, every member after Instance is an extension
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	Details   string                 `json:"details,omitempty"`
+	Fields    []FieldError           `json:"fields,omitempty"`
+	Retryable bool                   `json:"retryable,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ProblemType returns the type URI of an error code, e.g. <base>validation-error
+func ProblemType(code errors.ErrorCode) string {
+	return config.ProblemTypeBaseURL + strings.ReplaceAll(strings.ToLower(string(code)), "_", "-")
+}
+
+// wantsProblem reports whether the error should be rendered as problem details
+func wantsProblem(c *gin.Context) bool {
+	if config.ErrorFormat == FormatProblem {
+		return true
+	}
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == ProblemContentType {
+			return true
+		}
+	}
+	return false
+}
+
+func problem(c *gin.Context, trans ut.Translator, status int, info *ErrorInfo) {
+	title := http.StatusText(status)
+	if trans != nil {
+		title = i18n.Title(trans, info.Code, title)
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, Problem{
+		Type:      ProblemType(errors.ErrorCode(info.Code)),
+		Title:     title,
+		Status:    status,
+		Detail:    info.Message,
+		Instance:  c.GetString("request_id"),
+		Code:      info.Code,
+		Details:   info.Details,
+		Fields:    info.Fields,
+		Retryable: info.Retryable,
+		Metadata:  info.Metadata,
+	})
+}
@@ -2,11 +2,15 @@
 package response
 
 import (
+	"fmt"
 	"net/http"
 
 	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/i18n"
+	"go-user-service/internal/pkg/validator"
 
 	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
 )
 
 // Response represents standardized API response
@@ -18,9 +22,22 @@ type Response struct {
 }
 
 type ErrorInfo struct {
-	Code    string `json:"code"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	// RequestID lets clients quote the failing request to support
+	RequestID string `json:"request_id,omitempty"`
+	// Retryable tells clients the same request may succeed later
+	Retryable bool                   `json:"retryable,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// FieldError describes one input that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
 	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
 }
 
 type Meta struct {
@@ -46,28 +63,49 @@ func JSONWithMeta(c *gin.Context, statusCode int, data interface{}, meta *Meta)
 	})
 }
 
-// Error responses
+// Error responses, messages are translated to the locale negotiated by middleware.Locale.
+// Problem details are rendered instead of the envelope when configured or requested.
+// Driver and context errors are classified first, anything else is a generic 500.
 func Error(c *gin.Context, err error) {
-	var appErr *errors.AppError
-	if errors.As(err, &appErr) {
+	trans, _ := i18n.FromContext(c.Request.Context())
+
+	if appErr := errors.Classify(err); appErr != nil {
+		info := &ErrorInfo{
+			Code:      string(appErr.Code),
+			Message:   translate(trans, appErr.Code, appErr.Message),
+			Details:   appErr.Details,
+			Fields:    fieldErrors(appErr, trans),
+			RequestID: c.GetString("request_id"),
+			Retryable: appErr.Retryable,
+			Metadata:  appErr.Metadata,
+		}
+		if seconds, ok := appErr.Metadata["retry_after_seconds"]; ok {
+			c.Header("Retry-After", fmt.Sprint(seconds))
+		}
+		if wantsProblem(c) {
+			problem(c, trans, appErr.StatusCode, info)
+			return
+		}
 		c.JSON(appErr.StatusCode, Response{
 			Success: false,
-			Error: &ErrorInfo{
-				Code:    string(appErr.Code),
-				Message: appErr.Message,
-				Details: appErr.Details,
-			},
+			Error:   info,
 		})
 		return
 	}
 
 	// Handle unknown errors
+	info := &ErrorInfo{
+		Code:      string(errors.ErrCodeInternal),
+		Message:   translate(trans, errors.ErrCodeInternal, "Internal server error"),
+		RequestID: c.GetString("request_id"),
+	}
+	if wantsProblem(c) {
+		problem(c, trans, http.StatusInternalServerError, info)
+		return
+	}
 	c.JSON(http.StatusInternalServerError, Response{
 		Success: false,
-		Error: &ErrorInfo{
-			Code:    string(errors.ErrCodeInternal),
-			Message: "Internal server error",
-		},
+		Error:   info,
 	})
 }
 
@@ -103,3 +141,28 @@ func InternalError(c *gin.Context, err error) {
 	appErr := errors.Wrap(err, errors.ErrCodeInternal, "Internal server error")
 	Error(c, appErr)
 }
+
+func translate(trans ut.Translator, code errors.ErrorCode, message string) string {
+	if trans == nil {
+		return message
+	}
+	return i18n.Error(trans, string(code), message)
+}
+
+// fieldErrors lists the validation failures wrapped by err, if any
+func fieldErrors(err error, trans ut.Translator) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, ve := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   ve.Field,
+			Tag:     ve.Tag,
+			Message: ve.Translate(trans),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,231 @@
+package response
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/i18n"
+	"go-user-service/internal/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// render answers a request with Error(err), in locale when it is not empty
+func render(t *testing.T, catalog *i18n.Catalog, locale, accept string, err error) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	if locale != "" {
+		c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), catalog.Translator(locale)))
+	}
+	c.Set("request_id", "req-1")
+
+	Error(c, err)
+	return rec
+}
+
+// useConfig applies cfg for the rest of the test
+func useConfig(t *testing.T, cfg Config) {
+	t.Helper()
+	previous := config
+	Configure(cfg)
+	t.Cleanup(func() { config = previous })
+}
+
+func validationError(t *testing.T) error {
+	t.Helper()
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	req.Email = "budi"
+	err := validator.New().ValidateStruct(req)
+	if err == nil {
+		t.Fatal("ValidateStruct() succeeded, want a validation error")
+	}
+	return errors.Wrap(err, errors.ErrCodeValidation, "Invalid registration request")
+}
+
+func TestErrorContentNegotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		accept      string
+		wantProblem bool
+	}{
+		{"no accept header", FormatEnvelope, "", false},
+		{"json", FormatEnvelope, "application/json", false},
+		{"anything", FormatEnvelope, "*/*", false},
+		{"problem json", FormatEnvelope, "application/problem+json", true},
+		{"problem json among others", FormatEnvelope, "application/json;q=0.9, application/problem+json", true},
+		{"problem json with parameters", FormatEnvelope, "application/problem+json; charset=utf-8", true},
+		{"configured problem format", FormatProblem, "application/json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, Config{ErrorFormat: tt.format})
+
+			rec := render(t, nil, "", tt.accept, errors.New(errors.ErrCodeNotFound, "User not found"))
+
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404", rec.Code)
+			}
+			wantType := "application/json; charset=utf-8"
+			if tt.wantProblem {
+				wantType = ProblemContentType
+			}
+			if got := rec.Header().Get("Content-Type"); got != wantType {
+				t.Errorf("Content-Type = %q, want %q", got, wantType)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response does not parse: %v", err)
+			}
+			_, isEnvelope := body["success"]
+			if isEnvelope == tt.wantProblem {
+				t.Errorf("body = %s, want problem details %v", rec.Body, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	useConfig(t, Config{ErrorFormat: FormatEnvelope})
+
+	rec := render(t, nil, "", "", validationError(t))
+
+	var body Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response does not parse: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest || body.Success || body.Error == nil {
+		t.Fatalf("status = %d, body = %s, want a 400 error envelope", rec.Code, rec.Body)
+	}
+	if body.Error.Code != string(errors.ErrCodeValidation) || body.Error.Message != "Invalid registration request" {
+		t.Errorf("error = %s: %s", body.Error.Code, body.Error.Message)
+	}
+	if body.Error.RequestID != "req-1" {
+		t.Errorf("request_id = %q, want req-1", body.Error.RequestID)
+	}
+	if len(body.Error.Fields) != 1 || body.Error.Fields[0].Field != "email" || body.Error.Fields[0].Tag != "email" {
+		t.Errorf("fields = %+v, want the email field", body.Error.Fields)
+	}
+}
+
+func TestErrorProblem(t *testing.T) {
+	useConfig(t, Config{ErrorFormat: FormatEnvelope, ProblemTypeBaseURL: "https://api.example.com/errors/"})
+
+	rec := render(t, nil, "", ProblemContentType, validationError(t))
+
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response does not parse: %v", err)
+	}
+	want := Problem{
+		Type:     "https://api.example.com/errors/validation-error",
+		Title:    http.StatusText(http.StatusBadRequest),
+		Status:   http.StatusBadRequest,
+		Detail:   "Invalid registration request",
+		Instance: "req-1",
+		Code:     string(errors.ErrCodeValidation),
+	}
+	if body.Type != want.Type || body.Title != want.Title || body.Status != want.Status ||
+		body.Detail != want.Detail || body.Instance != want.Instance || body.Code != want.Code {
+		t.Errorf("problem = %+v, want %+v", body, want)
+	}
+	if len(body.Fields) != 1 || body.Fields[0].Field != "email" {
+		t.Errorf("fields = %+v, want the email field", body.Fields)
+	}
+}
+
+func TestErrorTranslation(t *testing.T) {
+	useConfig(t, Config{ErrorFormat: FormatEnvelope})
+	catalog, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("i18n.New() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		accept      string
+		err         error
+		wantMessage string
+		wantTitle   string
+	}{
+		{"no translator", "", "", errors.New(errors.ErrCodeNotFound, "User not found"), "User not found", ""},
+		{"source locale", "en", "", errors.New(errors.ErrCodeNotFound, "User not found"), "User not found", ""},
+		{"specific message", "id", "", errors.New(errors.ErrCodeNotFound, "User not found"), "Pengguna tidak ditemukan", ""},
+		{"generic code message", "id", "", errors.New(errors.ErrCodeNotFound, "Nothing here"), "Data yang diminta tidak ditemukan", ""},
+		{"unknown error", "id", "", stderrors.New("boom"), "Terjadi kesalahan pada server", ""},
+		{"problem title", "id", ProblemContentType, errors.New(errors.ErrCodeNotFound, "User not found"), "Pengguna tidak ditemukan", "Data yang diminta tidak ditemukan"},
+		{"problem title without translator", "", ProblemContentType, errors.New(errors.ErrCodeNotFound, "User not found"), "User not found", "Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := render(t, catalog, tt.locale, tt.accept, tt.err)
+
+			var body struct {
+				Error  ErrorInfo `json:"error"`
+				Title  string    `json:"title"`
+				Detail string    `json:"detail"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response does not parse: %v", err)
+			}
+			message := body.Error.Message
+			if tt.accept == ProblemContentType {
+				message = body.Detail
+			}
+			if message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+			if body.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", body.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestErrorUnknown(t *testing.T) {
+	useConfig(t, Config{ErrorFormat: FormatEnvelope})
+
+	rec := render(t, nil, "", "", stderrors.New("connection reset by peer"))
+
+	var body Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response does not parse: %v", err)
+	}
+	if rec.Code != http.StatusInternalServerError || body.Error == nil || body.Error.Code != string(errors.ErrCodeInternal) {
+		t.Fatalf("status = %d, body = %s, want a 500 INTERNAL_ERROR", rec.Code, rec.Body)
+	}
+	if body.Error.Message != "Internal server error" {
+		t.Errorf("message = %q, the cause must not leak", body.Error.Message)
+	}
+}
+
+func TestErrorRetryAfter(t *testing.T) {
+	useConfig(t, Config{ErrorFormat: FormatEnvelope})
+
+	err := errors.New(errors.ErrCodeRateLimited, "Too many requests").WithMetadata("retry_after_seconds", 30)
+	rec := render(t, nil, "", "", err)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+}
@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// FileProvider reads each secret from a file named after it in a directory,
+// the layout of Docker secrets (/run/secrets) and Kubernetes secret volumes
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a provider reading secrets from dir
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Get reads dir/name, the file is read again on every call to pick up rotations
+func (p *FileProvider) Get(_ context.Context, name string) (string, error) {
+	if name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	value, err := ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, err
+}
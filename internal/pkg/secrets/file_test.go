@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("signing-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider := NewFileProvider(dir)
+
+	tests := []struct {
+		name    string
+		secret  string
+		want    string
+		wantErr error
+	}{
+		{"present", "jwt_secret", "signing-key", nil},
+		{"missing", "db_password", "", ErrNotFound},
+		{"outside the directory", "../jwt_secret", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.Get(context.Background(), tt.secret)
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			case tt.want == "" && err == nil:
+				t.Errorf("Get() = %q, want an error", got)
+			case tt.want != "" && (err != nil || got != tt.want):
+				t.Errorf("Get() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
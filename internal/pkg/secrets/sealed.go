@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// KeySize is the length of a sealed file key
+const KeySize = 32
+
+const nonceSize = 24
+
+// Key encrypts and decrypts sealed secret files
+type Key [KeySize]byte
+
+// GenerateKey returns a new random key
+func GenerateKey() (*Key, error) {
+	var key Key
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ParseKey decodes a base64 key
+func ParseKey(encoded string) (*Key, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	if len(data) != KeySize {
+		return nil, fmt.Errorf("invalid secrets key: want %d bytes, got %d", KeySize, len(data))
+	}
+	var key Key
+	copy(key[:], data)
+	return &key, nil
+}
+
+// String returns the base64 encoding of the key
+func (k *Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// Seal encrypts secrets, a map of name to value, with NaCl secretbox
+func Seal(key *Key, secrets map[string]string) ([]byte, error) {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	k := [KeySize]byte(*key)
+	return secretbox.Seal(nonce[:], plain, &nonce, &k), nil
+}
+
+// Open decrypts data produced by Seal
+func Open(key *Key, data []byte) (map[string]string, error) {
+	if len(data) < nonceSize+secretbox.Overhead {
+		return nil, errors.New("sealed secrets file is too short")
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], data[:nonceSize])
+	k := [KeySize]byte(*key)
+	plain, ok := secretbox.Open(nil, data[nonceSize:], &nonce, &k)
+	if !ok {
+		return nil, errors.New("failed to decrypt sealed secrets file, wrong key or corrupted file")
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid sealed secrets file: %w", err)
+	}
+	return secrets, nil
+}
+
+// SealedFileProvider reads secrets from a local file encrypted with Seal
+type SealedFileProvider struct {
+	path string
+	key  *Key
+}
+
+// NewSealedFileProvider creates a provider for the sealed file at path
+func NewSealedFileProvider(path string, key *Key) *SealedFileProvider {
+	return &SealedFileProvider{path: path, key: key}
+}
+
+// Get decrypts the file and returns the secret called name. The file is read
+// again on every call, so replacing it rotates the secrets it holds.
+func (p *SealedFileProvider) Get(_ context.Context, name string) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	secrets, err := Open(p.key, data)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
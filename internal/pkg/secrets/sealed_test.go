@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newKey(t *testing.T) *Key {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+// writeSealed seals secrets with key into path
+func writeSealed(t *testing.T, path string, key *Key, secrets map[string]string) {
+	t.Helper()
+	data, err := Seal(key, secrets)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSealedFileProviderRoundTrip(t *testing.T) {
+	key := newKey(t)
+	path := filepath.Join(t.TempDir(), "secrets.sealed")
+	writeSealed(t, path, key, map[string]string{"jwt_secret": "signing-key", "db_password": "hunter2"})
+
+	// The key travels as base64, e.g. in SECRETS_SEALED_KEY_FILE
+	parsed, err := ParseKey(key.String())
+	if err != nil {
+		t.Fatalf("ParseKey() error = %v", err)
+	}
+	provider := NewSealedFileProvider(path, parsed)
+
+	for name, want := range map[string]string{"jwt_secret": "signing-key", "db_password": "hunter2"} {
+		got, err := provider.Get(context.Background(), name)
+		if err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := provider.Get(context.Background(), "redis_password"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(redis_password) error = %v, want ErrNotFound", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "hunter2") {
+		t.Error("sealed file holds a secret in plain text")
+	}
+}
+
+func TestSealedFileProviderRotation(t *testing.T) {
+	key := newKey(t)
+	path := filepath.Join(t.TempDir(), "secrets.sealed")
+	writeSealed(t, path, key, map[string]string{"jwt_secret": "v1"})
+
+	provider := NewSealedFileProvider(path, key)
+	value := NewValue("jwt.secret", "v1", FromProvider(provider, "jwt_secret"))
+
+	writeSealed(t, path, key, map[string]string{"jwt_secret": "v2"})
+	if changed, err := value.Refresh(context.Background()); !changed || err != nil {
+		t.Fatalf("Refresh() = %v, %v, want the rotated secret", changed, err)
+	}
+	if got := value.Versions(time.Hour); len(got) != 2 || got[0] != "v2" || got[1] != "v1" {
+		t.Errorf("Versions(1h) = %v, want [v2 v1]", got)
+	}
+
+	// A half written file fails to decrypt and keeps the current secrets
+	if err := os.WriteFile(path, []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := value.Refresh(context.Background()); changed || err == nil {
+		t.Errorf("Refresh() of a truncated file = %v, %v, want an error", changed, err)
+	}
+	if got := value.Get(); got != "v2" {
+		t.Errorf("Get() = %q, want v2", got)
+	}
+}
+
+func TestSealedFileProviderRejects(t *testing.T) {
+	key := newKey(t)
+	dir := t.TempDir()
+	sealed := filepath.Join(dir, "secrets.sealed")
+	writeSealed(t, sealed, key, map[string]string{"jwt_secret": "signing-key"})
+	short := filepath.Join(dir, "short.sealed")
+	if err := os.WriteFile(short, []byte("too short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		key  *Key
+		want string
+	}{
+		{"wrong key", sealed, newKey(t), "wrong key or corrupted file"},
+		{"truncated file", short, key, "too short"},
+		{"missing file", filepath.Join(dir, "missing.sealed"), key, "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSealedFileProvider(tt.path, tt.key).Get(context.Background(), "jwt_secret")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Get() error = %v, want %q", err, tt.want)
+			}
+			if errors.Is(err, ErrNotFound) {
+				t.Error("a failure to read the file is reported as a missing secret")
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"valid", newKey(t).String(), false},
+		{"not base64", "not base64!", true},
+		{"too short", "c2hvcnQ=", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && key.String() != tt.encoded {
+				t.Errorf("key.String() = %q, want %q", key.String(), tt.encoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Package secrets reads credentials from files and secret providers and keeps
+// them current, so rotated JWT keys and database passwords apply without a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+)
+
+// ErrNotFound is returned by providers that do not hold the requested secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider resolves secrets by name, e.g. "jwt_secret"
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Source reads the current value of one secret
+type Source func(ctx context.Context) (string, error)
+
+// FromFile reads a secret from a single file, e.g. a DB_PASSWORD_FILE
+func FromFile(path string) Source {
+	return func(context.Context) (string, error) {
+		return ReadFile(path)
+	}
+}
+
+// FromProvider reads the secret called name from provider
+func FromProvider(provider Provider, name string) Source {
+	return func(ctx context.Context) (string, error) {
+		return provider.Get(ctx, name)
+	}
+}
+
+// ReadFile returns the content of a secret file without its trailing newline
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Value holds the current and previous value of a secret. The previous value
+// lets JWTs signed before a rotation be verified until they expire.
+type Value struct {
+	name   string
+	source Source
+
+	mu       sync.RWMutex
+	current  string
+	previous string
+	// rotatedAt is when previous was replaced by current
+	rotatedAt time.Time
+	listeners []func(string)
+}
+
+// NewValue returns a secret starting at initial. A nil source never changes.
+func NewValue(name, initial string, source Source) *Value {
+	return &Value{name: name, source: source, current: initial}
+}
+
+// Name returns the name the secret was registered with
+func (v *Value) Name() string {
+	return v.name
+}
+
+// Get returns the current value
+func (v *Value) Get() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.current
+}
+
+// Versions returns the current value followed by the previous one until
+// maxAge has passed since the rotation, e.g. the lifetime of a JWT
+func (v *Value) Versions(maxAge time.Duration) []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.previous == "" || v.previous == v.current || time.Since(v.rotatedAt) >= maxAge {
+		return []string{v.current}
+	}
+	return []string{v.current, v.previous}
+}
+
+// OnChange registers fn to run with the new value after every rotation
+func (v *Value) OnChange(fn func(string)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.listeners = append(v.listeners, fn)
+}
+
+// Refresh re-reads the secret and reports whether it changed. Empty values
+// are ignored so a secret file being replaced is never read as a blank secret.
+func (v *Value) Refresh(ctx context.Context) (bool, error) {
+	if v.source == nil {
+		return false, nil
+	}
+	value, err := v.source(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	v.mu.Lock()
+	if value == "" || value == v.current {
+		v.mu.Unlock()
+		return false, nil
+	}
+	v.previous, v.current = v.current, value
+	v.rotatedAt = time.Now()
+	listeners := append([]func(string){}, v.listeners...)
+	v.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(value)
+	}
+	return true, nil
+}
+
+// Watch refreshes values every interval until ctx is done
+func Watch(ctx context.Context, interval time.Duration, l *logger.Logger, values ...*Value) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, value := range values {
+			changed, err := value.Refresh(ctx)
+			if err != nil {
+				l.LogError(err, "secret_refresh", map[string]interface{}{"secret": value.Name()})
+				continue
+			}
+			if changed {
+				l.WithField("secret", value.Name()).Info("Secret rotated")
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+)
+
+func TestValueRefresh(t *testing.T) {
+	var next string
+	var nextErr error
+	value := NewValue("jwt_secret", "v1", func(context.Context) (string, error) { return next, nextErr })
+
+	var rotated []string
+	value.OnChange(func(s string) { rotated = append(rotated, s) })
+
+	steps := []struct {
+		name         string
+		source       string
+		err          error
+		wantChanged  bool
+		wantVersions []string
+	}{
+		{"unchanged", "v1", nil, false, []string{"v1"}},
+		{"rotated", "v2", nil, true, []string{"v2", "v1"}},
+		{"file being replaced", "", nil, false, []string{"v2", "v1"}},
+		{"source failing", "", errors.New("permission denied"), false, []string{"v2", "v1"}},
+		{"rotated again", "v3", nil, true, []string{"v3", "v2"}},
+		{"rolled back", "v2", nil, true, []string{"v2", "v3"}},
+	}
+	for _, step := range steps {
+		next, nextErr = step.source, step.err
+		changed, err := value.Refresh(context.Background())
+		if !errors.Is(err, step.err) {
+			t.Errorf("%s: Refresh() error = %v, want %v", step.name, err, step.err)
+		}
+		if changed != step.wantChanged {
+			t.Errorf("%s: Refresh() changed = %v, want %v", step.name, changed, step.wantChanged)
+		}
+		if got := value.Versions(time.Hour); !reflect.DeepEqual(got, step.wantVersions) {
+			t.Errorf("%s: Versions(1h) = %v, want %v", step.name, got, step.wantVersions)
+		}
+		if got := value.Get(); got != step.wantVersions[0] {
+			t.Errorf("%s: Get() = %q, want %q", step.name, got, step.wantVersions[0])
+		}
+	}
+
+	if want := []string{"v2", "v3", "v2"}; !reflect.DeepEqual(rotated, want) {
+		t.Errorf("OnChange saw %v, want %v", rotated, want)
+	}
+}
+
+func TestValueWithoutSource(t *testing.T) {
+	value := NewValue("jwt_secret", "static", nil)
+	changed, err := value.Refresh(context.Background())
+	if changed || err != nil {
+		t.Errorf("Refresh() = %v, %v, want no change", changed, err)
+	}
+	if got := value.Versions(time.Hour); !reflect.DeepEqual(got, []string{"static"}) {
+		t.Errorf("Versions(1h) = %v", got)
+	}
+}
+
+func TestValueDropsPreviousAfterMaxAge(t *testing.T) {
+	next := "v1"
+	value := NewValue("jwt_secret", "v1", func(context.Context) (string, error) { return next, nil })
+	next = "v2"
+	if changed, err := value.Refresh(context.Background()); !changed || err != nil {
+		t.Fatalf("Refresh() = %v, %v, want the rotated secret", changed, err)
+	}
+
+	if got := value.Versions(time.Hour); !reflect.DeepEqual(got, []string{"v2", "v1"}) {
+		t.Errorf("Versions(1h) right after the rotation = %v, want [v2 v1]", got)
+	}
+
+	// Tokens signed with v1 have all expired an hour after the rotation
+	value.mu.Lock()
+	value.rotatedAt = time.Now().Add(-time.Hour)
+	value.mu.Unlock()
+	if got := value.Versions(time.Hour); !reflect.DeepEqual(got, []string{"v2"}) {
+		t.Errorf("Versions(1h) an hour after the rotation = %v, want [v2]", got)
+	}
+	if got := value.Versions(2 * time.Hour); !reflect.DeepEqual(got, []string{"v2", "v1"}) {
+		t.Errorf("Versions(2h) an hour after the rotation = %v, want [v2 v1]", got)
+	}
+}
+
+func TestValueFromReplacedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db_password")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old\n")
+	initial, err := ReadFile(path)
+	if err != nil || initial != "old" {
+		t.Fatalf("ReadFile() = %q, %v, want the content without its newline", initial, err)
+	}
+	value := NewValue("database.password", initial, FromFile(path))
+
+	// A writer truncates the file before writing the new secret
+	write("")
+	if changed, err := value.Refresh(context.Background()); changed || err != nil {
+		t.Errorf("Refresh() of a truncated file = %v, %v, want it ignored", changed, err)
+	}
+	write("\n")
+	if changed, _ := value.Refresh(context.Background()); changed {
+		t.Error("Refresh() took a blank line as the new secret")
+	}
+	if got := value.Get(); got != "old" {
+		t.Errorf("Get() = %q while the file is replaced, want old", got)
+	}
+
+	write("new\r\n")
+	if changed, err := value.Refresh(context.Background()); !changed || err != nil {
+		t.Fatalf("Refresh() = %v, %v, want the new secret", changed, err)
+	}
+	if got := value.Versions(time.Hour); !reflect.DeepEqual(got, []string{"new", "old"}) {
+		t.Errorf("Versions(1h) = %v, want [new old]", got)
+	}
+
+	// A missing file keeps the last value and reports why
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := value.Refresh(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Refresh() of a removed file error = %v, want not exist", err)
+	}
+	if got := value.Get(); got != "new" {
+		t.Errorf("Get() = %q after the file was removed, want new", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	var mu sync.Mutex
+	secret := "v1"
+	value := NewValue("jwt_secret", "v1", func(context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return secret, nil
+	})
+	rotated := make(chan string, 1)
+	value.OnChange(func(s string) { rotated <- s })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, time.Millisecond, logger.New("panic", "test"), value)
+		close(done)
+	}()
+
+	mu.Lock()
+	secret = "v2"
+	mu.Unlock()
+	select {
+	case got := <-rotated:
+		if got != "v2" {
+			t.Errorf("rotated to %q, want v2", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not pick up the rotation")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not return after cancel")
+	}
+}
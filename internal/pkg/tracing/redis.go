@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook creates a client span for every Redis command and pipeline
+type RedisHook struct{}
+
+// NewRedisHook creates a new Redis tracing hook
+func NewRedisHook() *RedisHook {
+	return &RedisHook{}
+}
+
+// DialHook implements redis.Hook
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := Start(ctx, "redis.dial",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis),
+		)
+		defer span.End()
+
+		conn, err := next(ctx, network, addr)
+		RecordError(span, err)
+		return conn, err
+	}
+}
+
+// ProcessHook implements redis.Hook
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName(cmd.Name()),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		if err != nil && err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.Name())
+		}
+
+		ctx, span := Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName(strings.Join(names, " ")),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		if err != nil && err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// useRecorder installs a tracer provider that records every ended span and
+// the W3C propagator for the duration of the test
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func findSpan(t *testing.T, spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	t.Fatalf("span %q not recorded, got %v", name, names)
+	return nil
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestInjectStartJob(t *testing.T) {
+	recorder := useRecorder(t)
+
+	ctx, producer := Start(context.Background(), "enqueue")
+	carrier := Inject(ctx)
+	producer.End()
+
+	if carrier["traceparent"] == "" {
+		t.Fatalf("Inject() = %v, want a traceparent", carrier)
+	}
+
+	_, consumer := StartJob(context.Background(), carrier, "job test", attribute.String("job.id", "1"))
+	consumer.End()
+
+	job := findSpan(t, recorder.Ended(), "job test")
+	if job.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("kind = %v, want consumer", job.SpanKind())
+	}
+	if job.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Errorf("trace ID = %s, want the producer's %s", job.SpanContext().TraceID(), producer.SpanContext().TraceID())
+	}
+	if job.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("parent = %s, want the producer span %s", job.Parent().SpanID(), producer.SpanContext().SpanID())
+	}
+	if links := job.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("links = %v, want one link to the producer span", links)
+	}
+	if v, _ := attr(job, "job.id"); v.AsString() != "1" {
+		t.Errorf("job.id = %q, want 1", v.AsString())
+	}
+}
+
+func TestStartJobWithoutCarrier(t *testing.T) {
+	recorder := useRecorder(t)
+
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Fatalf("Inject() without a span = %v, want nil", carrier)
+	}
+
+	_, span := StartJob(context.Background(), nil, "job orphan")
+	span.End()
+
+	job := findSpan(t, recorder.Ended(), "job orphan")
+	if job.Parent().IsValid() {
+		t.Errorf("parent = %v, want a root span", job.Parent())
+	}
+	if len(job.Links()) != 0 {
+		t.Errorf("links = %v, want none", job.Links())
+	}
+}
+
+type traceModel struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := useRecorder(t)
+
+	// DryRun builds statements and runs every callback without a server
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := db.Use(NewGormPlugin()); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	ctx, parent := Start(context.Background(), "request")
+	var models []traceModel
+	db.WithContext(ctx).Where("name = ?", "alice").Find(&models)
+	db.WithContext(ctx).Create(&traceModel{Name: "bob"})
+	parent.End()
+
+	spans := recorder.Ended()
+	for _, name := range []string{"gorm.query", "gorm.create"} {
+		span := findSpan(t, spans, name)
+		if span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("%s kind = %v, want client", name, span.SpanKind())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the request span", name)
+		}
+		if v, _ := attr(span, "db.collection.name"); v.AsString() != "trace_models" {
+			t.Errorf("%s db.collection.name = %q, want trace_models", name, v.AsString())
+		}
+	}
+
+	query := findSpan(t, spans, "gorm.query")
+	if v, _ := attr(query, "db.query.text"); v.AsString() != `SELECT * FROM "trace_models" WHERE name = $1` {
+		t.Errorf("db.query.text = %q", v.AsString())
+	}
+	if _, ok := attr(query, "db.rows_affected"); !ok {
+		t.Error("db.rows_affected not recorded")
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	recorder := useRecorder(t)
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	client.AddHook(NewRedisHook())
+
+	ctx, parent := Start(context.Background(), "request")
+	if err := client.Set(ctx, "key", "value", 0).Err(); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	if err := client.Get(ctx, "missing").Err(); err != redis.Nil {
+		t.Fatalf("GET error = %v, want redis.Nil", err)
+	}
+	if err := client.HGet(ctx, "key", "field").Err(); err == nil {
+		t.Fatal("HGET on a string succeeded, want WRONGTYPE")
+	}
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "counter")
+		pipe.Expire(ctx, "counter", 0)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("pipeline error = %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+
+	set := findSpan(t, spans, "redis.set")
+	if set.SpanKind() != trace.SpanKindClient {
+		t.Errorf("kind = %v, want client", set.SpanKind())
+	}
+	if set.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("redis.set is not a child of the request span")
+	}
+	if v, _ := attr(set, "db.system"); v.AsString() != "redis" {
+		t.Errorf("db.system = %q, want redis", v.AsString())
+	}
+
+	if get := findSpan(t, spans, "redis.get"); get.Status().Code == codes.Error {
+		t.Error("a cache miss marked the span as failed")
+	}
+	if hget := findSpan(t, spans, "redis.hget"); hget.Status().Code != codes.Error {
+		t.Errorf("redis.hget status = %v, want error", hget.Status())
+	}
+
+	pipeline := findSpan(t, spans, "redis.pipeline")
+	if v, _ := attr(pipeline, "db.operation.name"); v.AsString() != "incr expire" {
+		t.Errorf("db.operation.name = %q, want \"incr expire\"", v.AsString())
+	}
+	if v, _ := attr(pipeline, "db.redis.num_cmd"); v.AsInt64() != 2 {
+		t.Errorf("db.redis.num_cmd = %d, want 2", v.AsInt64())
+	}
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+
+	"go-user-service/internal/pkg/i18n"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// defaultTranslations mendaftarkan pesan aturan bawaan validator per locale
+var defaultTranslations = map[string]func(*validator.Validate, ut.Translator) error{
+	"en": en_translations.RegisterDefaultTranslations,
+	"id": id_translations.RegisterDefaultTranslations,
+}
+
+// customTranslations berisi pesan aturan kustom per locale, {0} adalah nama field
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"password": "{0} must be 8 to 72 bytes with upper and lower case letters, a digit and a special character",
+		"phone":    "{0} must be a valid phone number",
+		"username": "{0} must be 3-30 letters, digits, underscores or hyphens, not starting or ending with _ or -",
+		"locale":   "{0} must be a supported language",
+	},
+	"id": {
+		"password": "{0} harus 8-72 byte dengan huruf besar, kecil, angka, dan karakter khusus",
+		"phone":    "{0} harus berupa nomor telepon yang valid",
+		"username": "{0} harus 3-30 karakter, alfanumerik dengan underscore/hyphen (tidak di awal/akhir)",
+		"locale":   "{0} harus berupa bahasa yang didukung",
+	},
+}
+
+// RegisterTranslations mendaftarkan pesan validasi untuk setiap locale di catalog.
+// Pesan default ValidationError memakai locale default catalog, dan aturan
+// `locale` hanya menerima locale yang didukung catalog.
+func (v *Validator) RegisterTranslations(catalog *i18n.Catalog) error {
+	for _, locale := range catalog.Locales() {
+		trans := catalog.Translator(locale)
+
+		if register, ok := defaultTranslations[locale]; ok {
+			if err := register(v.validator, trans); err != nil {
+				return fmt.Errorf("failed to register %s validation messages: %w", locale, err)
+			}
+		}
+
+		for tag, text := range customTranslations[locale] {
+			tag, text := tag, text
+			err := v.validator.RegisterTranslation(tag, trans,
+				func(trans ut.Translator) error {
+					return trans.Add(tag, text, true)
+				},
+				func(trans ut.Translator, fe validator.FieldError) string {
+					message, err := trans.T(fe.Tag(), fe.Field())
+					if err != nil {
+						return fe.Error()
+					}
+					return message
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to register %s message for %s: %w", locale, tag, err)
+			}
+		}
+	}
+
+	v.trans = catalog.Translator(catalog.DefaultLocale())
+	v.isLocale = catalog.IsSupported
+	return nil
+}
+
+// message menghasilkan pesan default sebuah error validasi
+func (v *Validator) message(err validator.FieldError) string {
+	if v.trans != nil {
+		if message := err.Translate(v.trans); message != err.Error() {
+			return message
+		}
+	}
+	return getErrorMessage(err)
+}
+
+// isLocaleTag memeriksa format locale sebelum catalog didaftarkan, misalnya "en" atau "pt_br"
+func isLocaleTag(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(_[a-z]{2})?$`)
@@ -0,0 +1,163 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-user-service/internal/pkg/i18n"
+)
+
+type signupRequest struct {
+	Username string `json:"username" binding:"required,username"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,password"`
+	Phone    string `json:"phone" binding:"omitempty,phone"`
+	Locale   string `json:"locale" binding:"omitempty,locale"`
+}
+
+func validSignup() signupRequest {
+	return signupRequest{Username: "budi_santoso", Email: "budi@example.com", Password: "Rahasia1!"}
+}
+
+func validationErrors(t *testing.T, v *Validator, req signupRequest) ValidationErrors {
+	t.Helper()
+	var errs ValidationErrors
+	if err := v.ValidateStruct(req); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("ValidateStruct() error = %v, want one validation error", err)
+	}
+	return errs
+}
+
+func TestTranslatedFieldMessages(t *testing.T) {
+	catalog, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("i18n.New() error = %v", err)
+	}
+	v := New()
+	if err := v.RegisterTranslations(catalog); err != nil {
+		t.Fatalf("RegisterTranslations() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*signupRequest)
+		tag    string
+		en     string
+		id     string
+	}{
+		{
+			name:   "required",
+			modify: func(r *signupRequest) { r.Username = "" },
+			tag:    "required",
+			en:     "username is a required field",
+			id:     "username wajib diisi",
+		},
+		{
+			name:   "email",
+			modify: func(r *signupRequest) { r.Email = "budi" },
+			tag:    "email",
+			en:     "email must be a valid email address",
+			id:     "email harus berupa alamat email yang valid",
+		},
+		{
+			name:   "password",
+			modify: func(r *signupRequest) { r.Password = "rahasia" },
+			tag:    "password",
+			en:     "password must be 8 to 72 bytes with upper and lower case letters, a digit and a special character",
+			id:     "password harus 8-72 byte dengan huruf besar, kecil, angka, dan karakter khusus",
+		},
+		{
+			name:   "username",
+			modify: func(r *signupRequest) { r.Username = "-budi" },
+			tag:    "username",
+			en:     "username must be 3-30 letters, digits, underscores or hyphens, not starting or ending with _ or -",
+			id:     "username harus 3-30 karakter, alfanumerik dengan underscore/hyphen (tidak di awal/akhir)",
+		},
+		{
+			name:   "phone",
+			modify: func(r *signupRequest) { r.Phone = "12345" },
+			tag:    "phone",
+			en:     "phone must be a valid phone number",
+			id:     "phone harus berupa nomor telepon yang valid",
+		},
+		{
+			name:   "locale",
+			modify: func(r *signupRequest) { r.Locale = "fr" },
+			tag:    "locale",
+			en:     "locale must be a supported language",
+			id:     "locale harus berupa bahasa yang didukung",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validSignup()
+			tt.modify(&req)
+			fe := validationErrors(t, v, req)[0]
+
+			if fe.Tag != tt.tag {
+				t.Errorf("Tag = %q, want %q", fe.Tag, tt.tag)
+			}
+			if fe.Message != tt.en {
+				t.Errorf("Message = %q, want the default locale %q", fe.Message, tt.en)
+			}
+			if got := fe.Translate(catalog.Translator("en")); got != tt.en {
+				t.Errorf("Translate(en) = %q, want %q", got, tt.en)
+			}
+			if got := fe.Translate(catalog.Translator("id")); got != tt.id {
+				t.Errorf("Translate(id) = %q, want %q", got, tt.id)
+			}
+			if got := fe.Translate(nil); got != fe.Message {
+				t.Errorf("Translate(nil) = %q, want Message %q", got, fe.Message)
+			}
+		})
+	}
+}
+
+func TestMessagesWithoutTranslations(t *testing.T) {
+	v := New()
+
+	req := validSignup()
+	req.Password = "rahasia"
+	fe := validationErrors(t, v, req)[0]
+
+	want := "password harus 8-72 byte dengan huruf besar, kecil, angka, dan karakter khusus"
+	if fe.Message != want {
+		t.Errorf("Message = %q, want %q", fe.Message, want)
+	}
+
+	// Tanpa catalog aturan locale hanya memeriksa format
+	req = validSignup()
+	req.Locale = "fr"
+	if err := v.ValidateStruct(req); err != nil {
+		t.Errorf("ValidateStruct(locale fr) error = %v, want the format to pass", err)
+	}
+}
+
+func TestPasswordLength(t *testing.T) {
+	v := New()
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"minimum", "Rahasi1!", true},
+		{"too short", "Rahas1!", false},
+		{"72 bytes", "Rahasia1!" + strings.Repeat("a", 63), true},
+		{"73 bytes", "Rahasia1!" + strings.Repeat("a", 64), false},
+		{"multi-byte over 72 bytes", "Rahasia1!" + strings.Repeat("é", 32), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validSignup()
+			req.Password = tt.password
+			err := v.ValidateStruct(req)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateStruct(%d bytes) error = %v, want valid %v", len(tt.password), err, tt.valid)
+			}
+		})
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"regexp"
 	"strings"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
 // Validator membungkus instance validator
 type Validator struct {
 	validator *validator.Validate
+	// trans menerjemahkan pesan default, nil sampai RegisterTranslations dipanggil
+	trans ut.Translator
+	// isLocale memeriksa aturan `locale`
+	isLocale func(locale string) bool
 }
 
 // ValidationError merepresentasikan error validasi tunggal
@@ -21,6 +26,21 @@ type ValidationError struct {
 	Tag     string `json:"tag"`     // Tag validasi yang gagal
 	Value   string `json:"value"`   // Nilai yang gagal divalidasi
 	Message string `json:"message"` // Pesan error yang mudah dibaca
+
+	fieldError validator.FieldError
+}
+
+// Translate menghasilkan pesan error dalam bahasa trans, atau Message
+// bila aturan tersebut belum punya terjemahan
+func (e ValidationError) Translate(trans ut.Translator) string {
+	if e.fieldError == nil || trans == nil {
+		return e.Message
+	}
+	message := e.fieldError.Translate(trans)
+	if message == e.fieldError.Error() {
+		return e.Message
+	}
+	return message
 }
 
 // ValidationErrors koleksi error validasi
@@ -35,39 +55,95 @@ func (ve ValidationErrors) Error() string {
 	return strings.Join(messages, "; ")
 }
 
-// New membuat instance validator baru dengan konfigurasi default
+// New membuat instance validator baru dengan konfigurasi default.
+// Aturan dibaca dari tag `binding` agar sama dengan DTO yang di-bind oleh gin.
 func New() *Validator {
 	v := validator.New()
+	v.SetTagName("binding")
+	val := &Validator{validator: v, isLocale: isLocaleTag}
 
 	// Registrasi validasi kustom
 	v.RegisterValidation("password", validatePassword)
 	v.RegisterValidation("phone", validatePhone)
 	v.RegisterValidation("username", validateUsername)
+	v.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		return val.isLocale(fl.Field().String())
+	})
 
-	// Menggunakan nama json tag sebagai nama field
+	// Menggunakan nama json tag sebagai nama field, lalu form tag untuk query
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-		if name == "-" {
-			return ""
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
 		}
-		return name
+		return fld.Name
 	})
 
-	return &Validator{validator: v}
+	return val
 }
 
-// ValidateStruct memvalidasi struktur data
+// ValidateStruct memvalidasi struktur data, juga dipanggil oleh binding gin
+// untuk pointer ke struct dan slice of struct
 func (v *Validator) ValidateStruct(s interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(s)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.validateStruct(s)
+	case reflect.Slice, reflect.Array:
+		var validationErrors ValidationErrors
+		for i := 0; i < value.Len(); i++ {
+			err := v.ValidateStruct(value.Index(i).Interface())
+			var itemErrs ValidationErrors
+			if errors.As(err, &itemErrs) {
+				for _, itemErr := range itemErrs {
+					itemErr.Field = fmt.Sprintf("[%d].%s", i, itemErr.Field)
+					validationErrors = append(validationErrors, itemErr)
+				}
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(validationErrors) > 0 {
+			return validationErrors
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Engine mengembalikan validator bawaan, dipakai gin untuk registrasi tambahan
+func (v *Validator) Engine() interface{} {
+	return v.validator
+}
+
+func (v *Validator) validateStruct(s interface{}) error {
 	if err := v.validator.Struct(s); err != nil {
 		var valErrs validator.ValidationErrors
 		if errors.As(err, &valErrs) {
 			var validationErrors ValidationErrors
 			for _, err := range valErrs {
 				validationErrors = append(validationErrors, ValidationError{
-					Field:   err.Field(),
+					Field:   fieldPath(err),
 					Tag:     err.Tag(),
 					Value:   fmt.Sprintf("%v", err.Value()),
-					Message: getErrorMessage(err),
+					Message: v.message(err),
+
+					fieldError: err,
 				})
 			}
 			return validationErrors
@@ -77,11 +153,14 @@ func (v *Validator) ValidateStruct(s interface{}) error {
 	return nil
 }
 
-// validatePassword memvalidasi kekuatan password
+// maxPasswordBytes adalah panjang password terbesar yang diterima bcrypt
+const maxPasswordBytes = 72
+
+// validatePassword memvalidasi kekuatan dan panjang password dalam byte
 func validatePassword(fl validator.FieldLevel) bool {
 	password := fl.Field().String()
 
-	if len(password) < 8 {
+	if len(password) < 8 || len(password) > maxPasswordBytes {
 		return false
 	}
 
@@ -149,8 +228,10 @@ func getErrorMessage(err validator.FieldError) string {
 		return fmt.Sprintf("%s minimal harus %s karakter", field, err.Param())
 	case "max":
 		return fmt.Sprintf("%s maksimal harus %s karakter", field, err.Param())
+	case "url":
+		return fmt.Sprintf("%s harus berupa URL yang valid", field)
 	case "password":
-		return fmt.Sprintf("%s harus minimal 8 karakter dengan huruf besar, kecil, angka, dan karakter khusus", field)
+		return fmt.Sprintf("%s harus 8-72 byte dengan huruf besar, kecil, angka, dan karakter khusus", field)
 	case "phone":
 		return fmt.Sprintf("%s harus berupa nomor telepon yang valid", field)
 	case "username":
@@ -169,8 +250,18 @@ func (ve ValidationErrors) ToMap() map[string][]string {
 	return result
 }
 
-// IsValidationError mengecek apakah error merupakan error validasi
+// IsValidationError mengecek apakah error merupakan (atau membungkus) error validasi
 func IsValidationError(err error) bool {
-	_, ok := err.(ValidationErrors)
-	return ok
-}
\ No newline at end of file
+	var ve ValidationErrors
+	return errors.As(err, &ve)
+}
+
+// fieldPath mengembalikan path field tanpa nama struct teratas,
+// misalnya "event_types[0]" untuk CreateEndpointRequest.event_types[0]
+func fieldPath(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return err.Field()
+}
@@ -0,0 +1,427 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/lease"
+	"go-user-service/internal/pkg/logger"
+
+	redis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	cacheKeyPrefix = "user:cache:"
+	// cacheChannel carries the keys one replica invalidated so the others drop them from memory
+	cacheChannel = cacheKeyPrefix + "invalidate"
+	// cacheMiss is cached for lookups that found no user
+	cacheMiss = "-"
+	// lockPollInterval is how often a replica waiting on another one's load checks the cache
+	lockPollInterval = 25 * time.Millisecond
+)
+
+// cacheEntry is the cached form of a User. The password hash is left out so
+// it is never copied to Redis or process memory.
+type cacheEntry struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Locale    string    `json:"locale"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Cache keeps users in Redis, and briefly in process memory, for the
+// repositories returned by Wrap. Lookups by email cache the user ID, so
+// invalidating a user's ID key covers both lookups.
+type Cache struct {
+	redis  *redis.Client
+	cfg    config.UserCacheConfig
+	logger *logger.Logger
+	group  singleflight.Group
+	// local is nil when cfg.LocalTTL is 0
+	local *localCache
+}
+
+// NewCache creates a user cache on rdb
+func NewCache(rdb *redis.Client, cfg config.UserCacheConfig, l *logger.Logger) *Cache {
+	c := &Cache{redis: rdb, cfg: cfg, logger: l}
+	if cfg.LocalTTL > 0 {
+		c.local = newLocalCache(cfg.LocalTTL, cfg.LocalSize)
+	}
+	return c
+}
+
+// Wrap returns a Repository that serves FindByID and FindByEmail from the
+// cache and invalidates it on writes
+func (c *Cache) Wrap(repo Repository) Repository {
+	return &cachedRepository{Repository: repo, cache: c}
+}
+
+// Listen drops the in-process entries other replicas invalidate until ctx is
+// done. Invalidations published while the subscription reconnects are lost,
+// LocalTTL bounds how long such an entry stays stale.
+func (c *Cache) Listen(ctx context.Context) {
+	if c.local == nil {
+		return
+	}
+	sub := c.redis.Subscribe(ctx, cacheChannel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var keys []string
+			if err := json.Unmarshal([]byte(msg.Payload), &keys); err != nil {
+				c.logger.WithError(err).Warn("Ignoring malformed user cache invalidation")
+				continue
+			}
+			c.local.delete(keys...)
+		}
+	}
+}
+
+// load returns the value cached under key, calling fetch on a miss. fetch
+// returns "" when nothing exists, which is cached for NegativeTTL. Concurrent
+// misses share one fetch per process, and replicas wait on a short lock for
+// the one that fetches. Redis failures fall back to fetch.
+func (c *Cache) load(ctx context.Context, key string, fetch func(ctx context.Context) (string, error)) (string, error) {
+	if value, ok := c.local.get(key); ok {
+		return value, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		value, found, err := c.get(ctx, key)
+		if err != nil {
+			c.logger.WithError(err).WithField("key", key).Warn("User cache unavailable, reading from the database")
+			return fetch(ctx)
+		}
+		if found {
+			return value, nil
+		}
+
+		lock := lease.New(c.redis, key+":lock", c.cfg.LockTTL)
+		acquired, err := lock.Acquire(ctx)
+		if err != nil {
+			return fetch(ctx)
+		}
+		if !acquired {
+			if value, found := c.await(ctx, key); found {
+				return value, nil
+			}
+			return fetch(ctx)
+		}
+		defer lock.Release(context.WithoutCancel(ctx))
+
+		value, err = fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.set(ctx, key, value)
+		return value, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// get reads key from Redis, found is false on a miss
+func (c *Cache) get(ctx context.Context, key string) (string, bool, error) {
+	raw, err := c.redis.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if raw == cacheMiss {
+		raw = ""
+	}
+	c.local.set(key, raw)
+	return raw, true, nil
+}
+
+// await polls key while another replica holds its lock, giving up after LockTTL
+func (c *Cache) await(ctx context.Context, key string) (string, bool) {
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(c.cfg.LockTTL)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-timeout.C:
+			return "", false
+		case <-ticker.C:
+			value, found, err := c.get(ctx, key)
+			if err != nil {
+				return "", false
+			}
+			if found {
+				return value, true
+			}
+		}
+	}
+}
+
+// set caches value under key, "" as a miss
+func (c *Cache) set(ctx context.Context, key, value string) {
+	raw, ttl := value, c.cfg.TTL
+	if value == "" {
+		raw, ttl = cacheMiss, c.cfg.NegativeTTL
+	}
+	if err := c.redis.Set(ctx, key, raw, ttl).Err(); err != nil {
+		c.logger.WithError(err).WithField("key", key).Warn("Failed to cache user")
+		return
+	}
+	c.local.set(key, value)
+}
+
+// Forget drops the cached copies of users changed outside a cached
+// repository, e.g. by a bulk delete. Only their ID and Email are used.
+func (c *Cache) Forget(ctx context.Context, users ...User) {
+	if len(users) == 0 {
+		return
+	}
+	keys := make([]string, 0, 2*len(users))
+	for _, user := range users {
+		keys = append(keys, idKey(user.ID), emailKey(user.Email))
+	}
+	c.invalidate(ctx, keys...)
+}
+
+// invalidate drops keys here, in Redis and in the memory of every other replica
+func (c *Cache) invalidate(ctx context.Context, keys ...string) {
+	c.local.delete(keys...)
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		c.logger.WithError(err).WithField("keys", keys).Error("Failed to invalidate user cache")
+	}
+	payload, _ := json.Marshal(keys)
+	if err := c.redis.Publish(ctx, cacheChannel, payload).Err(); err != nil {
+		c.logger.WithError(err).WithField("keys", keys).Warn("Failed to publish user cache invalidation")
+	}
+}
+
+func idKey(id uint) string {
+	return cacheKeyPrefix + "id:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func emailKey(email string) string {
+	return cacheKeyPrefix + "email:" + email
+}
+
+func encodeUser(user *User) (string, error) {
+	raw, err := json.Marshal(cacheEntry{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Locale:    user.Locale,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	})
+	return string(raw), err
+}
+
+func decodeUser(raw string) (*User, error) {
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		return nil, err
+	}
+	return &User{
+		ID:        entry.ID,
+		Username:  entry.Username,
+		Email:     entry.Email,
+		Locale:    entry.Locale,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}, nil
+}
+
+// cachedRepository is the cache-aside decorator returned by Cache.Wrap. Cache
+// fills read the primary, so a lagging replica cannot cache a user that was
+// just invalidated. Users read through the cache have no password hash, a
+// credential check must read the user with the plain repository.
+type cachedRepository struct {
+	Repository
+	cache *Cache
+	// tx is set by WithTx, reads then bypass the cache so they see the
+	// transaction's own writes, and invalidation waits for the commit
+	tx *gorm.DB
+}
+
+func (r *cachedRepository) WithTx(tx *gorm.DB) Repository {
+	return &cachedRepository{Repository: r.Repository.WithTx(tx), cache: r.cache, tx: tx}
+}
+
+// Create also drops a cached miss for the new user's email or ID
+func (r *cachedRepository) Create(ctx context.Context, user *User) error {
+	if err := r.Repository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.invalidate(ctx, idKey(user.ID), emailKey(user.Email))
+	return nil
+}
+
+func (r *cachedRepository) UpdateLocale(ctx context.Context, id uint, locale string) (bool, error) {
+	found, err := r.Repository.UpdateLocale(ctx, id, locale)
+	r.invalidate(ctx, idKey(id))
+	return found, err
+}
+
+// invalidate drops keys now, or once the transaction of r commits. Dropping
+// them earlier would let a concurrent read cache the state before the commit.
+func (r *cachedRepository) invalidate(ctx context.Context, keys ...string) {
+	if r.tx == nil {
+		r.cache.invalidate(ctx, keys...)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	database.AfterCommit(r.tx, func() {
+		r.cache.invalidate(ctx, keys...)
+	})
+}
+
+func (r *cachedRepository) FindByID(ctx context.Context, id uint) (*User, error) {
+	if r.tx != nil {
+		return r.Repository.FindByID(ctx, id)
+	}
+	raw, err := r.cache.load(ctx, idKey(id), func(ctx context.Context) (string, error) {
+		user, err := r.Repository.FindByID(database.WithPrimary(ctx), id)
+		if err != nil || user == nil {
+			return "", err
+		}
+		return encodeUser(user)
+	})
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	return decodeUser(raw)
+}
+
+// FindByEmail caches the ID of the user, then loads the user by ID
+func (r *cachedRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	if r.tx != nil {
+		return r.Repository.FindByEmail(ctx, email)
+	}
+	raw, err := r.cache.load(ctx, emailKey(email), func(ctx context.Context) (string, error) {
+		user, err := r.Repository.FindByEmail(database.WithPrimary(ctx), email)
+		if err != nil || user == nil {
+			return "", err
+		}
+		return strconv.FormatUint(uint64(user.ID), 10), nil
+	})
+	if err != nil || raw == "" {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.FindByID(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	// The ID may belong to a user that was deleted since, look the email up again
+	if user == nil || user.Email != email {
+		r.cache.invalidate(ctx, emailKey(email))
+		return r.Repository.FindByEmail(ctx, email)
+	}
+	return user, nil
+}
+
+// localCache is the in-process layer in front of Redis. Its methods are
+// no-ops on a nil cache.
+type localCache struct {
+	ttl  time.Duration
+	size int
+
+	mu      sync.Mutex
+	entries map[string]localEntry
+}
+
+type localEntry struct {
+	value   string
+	expires time.Time
+}
+
+func newLocalCache(ttl time.Duration, size int) *localCache {
+	return &localCache{ttl: ttl, size: size, entries: make(map[string]localEntry)}
+}
+
+func (l *localCache) get(key string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(l.entries, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (l *localCache) set(key, value string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.entries[key]; !ok && len(l.entries) >= l.size {
+		l.evict()
+	}
+	l.entries[key] = localEntry{value: value, expires: time.Now().Add(l.ttl)}
+}
+
+func (l *localCache) delete(keys ...string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.entries, key)
+	}
+}
+
+// evict drops expired entries, or an arbitrary one when none has expired
+func (l *localCache) evict() {
+	now := time.Now()
+	for key, entry := range l.entries {
+		if now.After(entry.expires) {
+			delete(l.entries, key)
+		}
+	}
+	if len(l.entries) < l.size {
+		return
+	}
+	for key := range l.entries {
+		delete(l.entries, key)
+		return
+	}
+}
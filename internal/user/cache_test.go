@@ -0,0 +1,437 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/config"
+	"go-user-service/internal/pkg/database"
+	"go-user-service/internal/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// memoryRepository keeps users in a map and counts the lookups that reach it
+type memoryRepository struct {
+	Repository
+
+	mu    sync.Mutex
+	users map[uint]User
+	// lookups counts FindByID and FindByEmail calls, unpinned those not sent to the primary
+	lookups  int
+	unpinned int
+	// block holds FindByID until it is closed, when set
+	block chan struct{}
+}
+
+func newMemoryRepository(users ...User) *memoryRepository {
+	r := &memoryRepository{users: make(map[uint]User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *memoryRepository) WithTx(*gorm.DB) Repository { return r }
+
+func (r *memoryRepository) Lookups() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups
+}
+
+func (r *memoryRepository) lookup(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	if !database.UsesPrimary(ctx) {
+		r.unpinned++
+	}
+}
+
+func (r *memoryRepository) Create(_ context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uint) (*User, error) {
+	if r.block != nil {
+		<-r.block
+	}
+	r.lookup(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *memoryRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	r.lookup(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) UpdateLocale(_ context.Context, id uint, locale string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return false, nil
+	}
+	user.Locale = locale
+	r.users[id] = user
+	return true, nil
+}
+
+func testCacheConfig() config.UserCacheConfig {
+	return config.UserCacheConfig{
+		Enabled:     true,
+		TTL:         5 * time.Minute,
+		NegativeTTL: 30 * time.Second,
+		LockTTL:     time.Second,
+	}
+}
+
+// newTestCaches returns one cache per replica, all on the same Redis
+func newTestCaches(t *testing.T, cfg config.UserCacheConfig, replicas int) (*miniredis.Miniredis, []*Cache) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	l := logger.New("panic", "test")
+
+	caches := make([]*Cache, replicas)
+	for i := range caches {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		caches[i] = NewCache(client, cfg, l)
+	}
+	return server, caches
+}
+
+func budi() User {
+	return User{ID: 1, Username: "budi", Email: "budi@example.com", Password: "$2a$10$hash", Locale: "en"}
+}
+
+func TestCacheHit(t *testing.T) {
+	server, caches := newTestCaches(t, testCacheConfig(), 1)
+	repo := newMemoryRepository(budi())
+	users := caches[0].Wrap(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		user, err := users.FindByID(ctx, 1)
+		if err != nil || user == nil || user.Username != "budi" {
+			t.Fatalf("FindByID() = %+v, %v, want budi", user, err)
+		}
+		if user.Password != "" {
+			t.Error("FindByID() returned the password hash from the cache")
+		}
+	}
+	if got := repo.Lookups(); got != 1 {
+		t.Errorf("repository read %d times, want 1", got)
+	}
+	if repo.unpinned != 0 {
+		t.Errorf("%d cache fills read a replica, want the primary", repo.unpinned)
+	}
+
+	raw, err := server.Get(idKey(1))
+	if err != nil || strings.Contains(raw, "hash") {
+		t.Errorf("cached %q, %v, want the user without its password", raw, err)
+	}
+	if ttl := server.TTL(idKey(1)); ttl != 5*time.Minute {
+		t.Errorf("TTL = %s, want 5m", ttl)
+	}
+
+	// A lookup by email caches the ID and reuses the cached user
+	if user, err := users.FindByEmail(ctx, "budi@example.com"); err != nil || user == nil || user.ID != 1 {
+		t.Fatalf("FindByEmail() = %+v, %v, want budi", user, err)
+	}
+	if raw, _ := server.Get(emailKey("budi@example.com")); raw != "1" {
+		t.Errorf("email key holds %q, want the ID", raw)
+	}
+	if got := repo.Lookups(); got != 2 {
+		t.Errorf("repository read %d times, want 2", got)
+	}
+}
+
+func TestCacheCoalescesMisses(t *testing.T) {
+	_, caches := newTestCaches(t, testCacheConfig(), 1)
+	repo := newMemoryRepository(budi())
+	repo.block = make(chan struct{})
+	users := caches[0].Wrap(repo)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make(chan *User, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := users.FindByID(context.Background(), 1)
+			if err != nil {
+				t.Errorf("FindByID() error = %v", err)
+			}
+			results <- user
+		}()
+	}
+
+	// Let every caller reach the cache before the first fetch returns
+	time.Sleep(50 * time.Millisecond)
+	close(repo.block)
+	wg.Wait()
+	close(results)
+
+	for user := range results {
+		if user == nil || user.ID != 1 {
+			t.Errorf("FindByID() = %+v, want budi", user)
+		}
+	}
+	if got := repo.Lookups(); got != 1 {
+		t.Errorf("%d concurrent misses read the repository %d times, want once", callers, got)
+	}
+}
+
+func TestCacheWaitsForOtherReplica(t *testing.T) {
+	server, caches := newTestCaches(t, testCacheConfig(), 2)
+	repo := newMemoryRepository(budi())
+
+	// The first replica is loading the user
+	server.Set(idKey(1)+":lock", "first")
+
+	done := make(chan *User)
+	go func() {
+		user, err := caches[1].Wrap(repo).FindByID(context.Background(), 1)
+		if err != nil {
+			t.Errorf("FindByID() error = %v", err)
+		}
+		done <- user
+	}()
+
+	time.Sleep(2 * lockPollInterval)
+	stored := budi()
+	raw, err := encodeUser(&stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Set(idKey(1), raw)
+
+	select {
+	case user := <-done:
+		if user == nil || user.ID != 1 {
+			t.Errorf("FindByID() = %+v, want the user cached by the first replica", user)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("FindByID() did not return once the user was cached")
+	}
+	if got := repo.Lookups(); got != 0 {
+		t.Errorf("waiting replica read the repository %d times, want 0", got)
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	server, caches := newTestCaches(t, testCacheConfig(), 1)
+	repo := newMemoryRepository()
+	users := caches[0].Wrap(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if user, err := users.FindByID(ctx, 1); user != nil || err != nil {
+			t.Fatalf("FindByID() = %+v, %v, want no user", user, err)
+		}
+		if user, err := users.FindByEmail(ctx, "budi@example.com"); user != nil || err != nil {
+			t.Fatalf("FindByEmail() = %+v, %v, want no user", user, err)
+		}
+	}
+	if got := repo.Lookups(); got != 2 {
+		t.Errorf("repository read %d times, want each miss once", got)
+	}
+	for _, key := range []string{idKey(1), emailKey("budi@example.com")} {
+		if raw, _ := server.Get(key); raw != cacheMiss {
+			t.Errorf("%s holds %q, want the miss marker", key, raw)
+		}
+		if ttl := server.TTL(key); ttl != 30*time.Second {
+			t.Errorf("%s TTL = %s, want the negative TTL", key, ttl)
+		}
+	}
+
+	// Creating the user drops the cached misses
+	user := budi()
+	if err := users.Create(ctx, &user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if found, err := users.FindByID(ctx, 1); err != nil || found == nil {
+		t.Errorf("FindByID() after Create() = %+v, %v, want the new user", found, err)
+	}
+	if found, err := users.FindByEmail(ctx, user.Email); err != nil || found == nil {
+		t.Errorf("FindByEmail() after Create() = %+v, %v, want the new user", found, err)
+	}
+
+	// Misses expire after the negative TTL
+	if _, err := users.FindByID(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(30 * time.Second)
+	if server.Exists(idKey(2)) {
+		t.Error("a miss outlived the negative TTL")
+	}
+}
+
+// txPool is a connection pool whose transactions only record how they end
+type txPool struct {
+	gorm.ConnPool
+	ended []string
+}
+
+func (p *txPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (p *txPool) Commit() error   { p.ended = append(p.ended, "COMMIT"); return nil }
+func (p *txPool) Rollback() error { p.ended = append(p.ended, "ROLLBACK"); return nil }
+
+// openTxDB returns a gorm session whose transactions need no server
+func openTxDB(t *testing.T) (*gorm.DB, *txPool) {
+	t.Helper()
+	pool := &txPool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db, pool
+}
+
+func TestCacheInvalidatesAfterCommit(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCached  bool
+		wantEnd     string
+		wantReadNew string
+	}{
+		{"commit", nil, false, "COMMIT", "id"},
+		{"rollback", errAbort, true, "ROLLBACK", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, caches := newTestCaches(t, testCacheConfig(), 1)
+			repo := newMemoryRepository(budi())
+			users := caches[0].Wrap(repo)
+			db, pool := openTxDB(t)
+			ctx := context.Background()
+
+			if _, err := users.FindByID(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			err := database.WithTransaction(db, func(tx *gorm.DB) error {
+				txUsers := users.WithTx(tx)
+				if _, err := txUsers.UpdateLocale(ctx, 1, "id"); err != nil {
+					return err
+				}
+				if !server.Exists(idKey(1)) {
+					t.Error("invalidated before the commit, a concurrent read could cache the old user")
+				}
+				// Reads in the transaction see its own writes
+				user, err := txUsers.FindByID(ctx, 1)
+				if err != nil || user == nil || user.Locale != "id" {
+					t.Errorf("FindByID() in the transaction = %+v, %v, want locale id", user, err)
+				}
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("WithTransaction() error = %v, want %v", err, tt.err)
+			}
+			if len(pool.ended) != 1 || pool.ended[0] != tt.wantEnd {
+				t.Fatalf("transaction ended with %v, want %s", pool.ended, tt.wantEnd)
+			}
+
+			if got := server.Exists(idKey(1)); got != tt.wantCached {
+				t.Errorf("cached after the transaction = %v, want %v", got, tt.wantCached)
+			}
+			if tt.err != nil {
+				// The rolled back write never reached the database
+				repo.UpdateLocale(ctx, 1, "en")
+			}
+			user, err := users.FindByID(ctx, 1)
+			if err != nil || user == nil || user.Locale != tt.wantReadNew {
+				t.Errorf("FindByID() after the transaction = %+v, %v, want locale %s", user, err, tt.wantReadNew)
+			}
+		})
+	}
+}
+
+func TestCacheListenDropsLocalEntries(t *testing.T) {
+	cfg := testCacheConfig()
+	cfg.LocalTTL, cfg.LocalSize = time.Minute, 100
+	server, caches := newTestCaches(t, cfg, 2)
+	repo := newMemoryRepository(budi())
+	writer, reader := caches[0].Wrap(repo), caches[1].Wrap(repo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go caches[1].Listen(ctx)
+	deadline := time.Now().Add(time.Second)
+	for server.PubSubNumSub(cacheChannel)[cacheChannel] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Listen() did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := reader.FindByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	// The reader now serves the user from memory, even once Redis forgot it
+	server.Del(idKey(1))
+	if user, _ := reader.FindByID(ctx, 1); user == nil || user.Locale != "en" {
+		t.Fatalf("FindByID() = %+v, want the user from memory", user)
+	}
+	lookups := repo.Lookups()
+
+	if _, err := writer.UpdateLocale(ctx, 1, "id"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for {
+		user, err := reader.FindByID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Locale == "id" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the reader kept the invalidated user in memory")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := repo.Lookups(); got != lookups+1 {
+		t.Errorf("repository read %d more times, want 1 after the invalidation", got-lookups)
+	}
+}
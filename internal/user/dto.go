@@ -1,13 +1,47 @@
 package user
 
+import "time"
+
 type CreateUserRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required,username"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required,password"`
+	Locale   string `json:"locale" binding:"omitempty,locale"`
+}
+
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale" binding:"required,locale"`
+}
+
+// ListUsersRequest pages through users by ID, starting after AfterID
+type ListUsersRequest struct {
+	AfterID uint `form:"after_id"`
+	Limit   int  `form:"limit" binding:"omitempty,min=1"`
 }
 
 type UserResponse struct {
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Locale    string    `json:"locale,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListUsersResult is one page of users ordered by ID
+type ListUsersResult struct {
+	Users []UserResponse `json:"users"`
+	// NextAfterID is the cursor of the next page, zero when there are no more users
+	NextAfterID uint `json:"next_after_id,omitempty"`
+}
+
+func toUserResponse(user *User) *UserResponse {
+	return &UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Locale:    user.Locale,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
 }
@@ -1,13 +1,20 @@
 package user
 
 import (
+	"go-user-service/internal/pkg/auth"
 	"go-user-service/internal/pkg/errors"
 	"go-user-service/internal/pkg/response"
-	"net/http"
+	"go-user-service/internal/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
+// List page sizes, larger limits are capped rather than rejected
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
 type Handler struct {
 	service Service
 }
@@ -18,26 +25,73 @@ func NewHandler(service Service) *Handler {
 
 func (h *Handler) RegisRoutes(rg *gin.RouterGroup) {
 	users := rg.Group("/users")
-	users.GET("/", h.GetAll)
 	users.POST("/register", h.Register)
 }
 
+// RegisAuthRoutes registers the routes of the signed-in user, rg must be behind middleware.Auth
+func (h *Handler) RegisAuthRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/users/me/locale", h.UpdateLocale)
+}
+
+// RegisAdminRoutes registers the routes over every user, rg must be behind middleware.AdminKey
+func (h *Handler) RegisAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users", h.GetAll)
+}
+
+// GetAll returns one page of users, pass next_after_id as after_id for the next one
 func (h *Handler) GetAll(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "list all users"})
+	var req ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid query parameters"))
+		return
+	}
+	switch {
+	case req.Limit == 0:
+		req.Limit = defaultListLimit
+	case req.Limit > maxListLimit:
+		req.Limit = maxListLimit
+	}
+
+	result, appErr := h.service.List(c.Request.Context(), req.AfterID, req.Limit)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, result)
 }
 
 func (h *Handler) Register(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "user.Handler.Register")
+	defer span.End()
+
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		err := errors.New(errors.ErrCodeValidation, "Invalid user ID format")
-		response.Error(c, err)
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid registration request"))
 		return
 	}
-	user, err := h.service.Register(c.Request.Context(), req)
+	user, err := h.service.Register(ctx, req)
 	if err != nil {
+		tracing.RecordError(span, err)
 		response.Error(c, err)
 		return
 	}
 
 	response.Created(c, user)
 }
+
+func (h *Handler) UpdateLocale(c *gin.Context) {
+	userID, _ := auth.UserID(c.Request.Context())
+
+	var req UpdateLocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid locale"))
+		return
+	}
+
+	user, err := h.service.UpdateLocale(c.Request.Context(), userID, req.Locale)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, user)
+}
@@ -0,0 +1,248 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/auth"
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/i18n"
+	"go-user-service/internal/pkg/middleware"
+	"go-user-service/internal/pkg/secrets"
+	"go-user-service/internal/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// listService records the page requested from List
+type listService struct {
+	Service
+	afterID uint
+	limit   int
+}
+
+func (s *listService) List(_ context.Context, afterID uint, limit int) (*ListUsersResult, *errors.AppError) {
+	s.afterID, s.limit = afterID, limit
+	return &ListUsersResult{
+		Users:       []UserResponse{{ID: afterID + 1, Username: "budi"}},
+		NextAfterID: afterID + 1,
+	}, nil
+}
+
+func TestHandlerGetAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantAfterID uint
+		wantLimit   int
+	}{
+		{"first page", "", http.StatusOK, 0, defaultListLimit},
+		{"next page", "?after_id=40&limit=10", http.StatusOK, 40, 10},
+		{"caps the limit", "?limit=100000", http.StatusOK, 0, maxListLimit},
+		{"negative limit", "?limit=-1", http.StatusBadRequest, 0, 0},
+		{"malformed cursor", "?after_id=abc", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &listService{}
+			router := gin.New()
+			router.GET("/users", NewHandler(service).GetAll)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if service.afterID != tt.wantAfterID || service.limit != tt.wantLimit {
+				t.Errorf("List(after %d, limit %d), want (after %d, limit %d)",
+					service.afterID, service.limit, tt.wantAfterID, tt.wantLimit)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data struct {
+					Users       []UserResponse `json:"users"`
+					NextAfterID uint           `json:"next_after_id"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response does not parse: %v", err)
+			}
+			if len(body.Data.Users) != 1 || body.Data.NextAfterID != tt.wantAfterID+1 {
+				t.Errorf("data = %+v, want one user and the next cursor", body.Data)
+			}
+		})
+	}
+}
+
+// registerService counts the registrations that got past validation
+type registerService struct {
+	Service
+	calls int
+}
+
+func (s *registerService) Register(_ context.Context, req CreateUserRequest) (*UserResponse, *errors.AppError) {
+	s.calls++
+	return &UserResponse{ID: 1, Username: req.Username, Email: req.Email}, nil
+}
+
+func TestHandlerRegisterValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	catalog, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("i18n.New() error = %v", err)
+	}
+	v := validator.New()
+	if err := v.RegisterTranslations(catalog); err != nil {
+		t.Fatalf("RegisterTranslations() error = %v", err)
+	}
+	previous := binding.Validator
+	binding.Validator = v
+	t.Cleanup(func() { binding.Validator = previous })
+
+	type field struct{ Field, Tag string }
+	valid := map[string]string{"username": "budi_santoso", "email": "budi@example.com", "password": "Rahasia1!"}
+	with := func(key, value string) map[string]string {
+		req := map[string]string{}
+		for k, v := range valid {
+			req[k] = v
+		}
+		req[key] = value
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantFields []field
+	}{
+		{"valid", valid, nil},
+		{"empty body", map[string]string{}, []field{{"username", "required"}, {"email", "required"}, {"password", "required"}}},
+		{"username longer than the column", with("username", strings.Repeat("a", 31)), []field{{"username", "username"}}},
+		{"username too short", with("username", "ab"), []field{{"username", "username"}}},
+		{"username with spaces", with("username", "budi santoso"), []field{{"username", "username"}}},
+		{"username ending with a hyphen", with("username", "budi-"), []field{{"username", "username"}}},
+		{"invalid email", with("email", "budi"), []field{{"email", "email"}}},
+		{"weak password", with("password", "rahasia123"), []field{{"password", "password"}}},
+		{"password over 72 bytes", with("password", "Rahasia1!"+strings.Repeat("a", 64)), []field{{"password", "password"}}},
+		{"multi-byte password over 72 bytes", with("password", "Rahasia1!"+strings.Repeat("é", 32)), []field{{"password", "password"}}},
+		{"unsupported locale", with("locale", "xx"), []field{{"locale", "locale"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &registerService{}
+			router := gin.New()
+			router.POST("/users/register", NewHandler(service).Register)
+
+			payload, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, "/users/register", bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if tt.wantFields == nil {
+				if rec.Code != http.StatusCreated || service.calls != 1 {
+					t.Fatalf("status = %d after %d registrations, want 201: %s", rec.Code, service.calls, rec.Body)
+				}
+				return
+			}
+			if rec.Code != http.StatusBadRequest || service.calls != 0 {
+				t.Fatalf("status = %d after %d registrations, want 400: %s", rec.Code, service.calls, rec.Body)
+			}
+
+			var body struct {
+				Error struct {
+					Code   string `json:"code"`
+					Fields []struct {
+						Field   string `json:"field"`
+						Tag     string `json:"tag"`
+						Message string `json:"message"`
+					} `json:"fields"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response does not parse: %v", err)
+			}
+			if body.Error.Code != string(errors.ErrCodeValidation) {
+				t.Errorf("code = %q, want %q", body.Error.Code, errors.ErrCodeValidation)
+			}
+			if len(body.Error.Fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %+v, want %v", body.Error.Fields, tt.wantFields)
+			}
+			for i, want := range tt.wantFields {
+				got := body.Error.Fields[i]
+				if got.Field != want.Field || got.Tag != want.Tag {
+					t.Errorf("fields[%d] = %s/%s, want %s/%s", i, got.Field, got.Tag, want.Field, want.Tag)
+				}
+				if got.Message == "" {
+					t.Errorf("fields[%d] has no message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerListRequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, err := auth.NewToken("jwt-secret", 7, time.Hour)
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		adminKey   string
+		wantStatus int
+	}{
+		{"signed-in user on the old route", "/api/v1/users", "", http.StatusNotFound},
+		{"signed-in user", "/api/v1/admin/users", "", http.StatusForbidden},
+		{"wrong admin key", "/api/v1/admin/users", "guess", http.StatusForbidden},
+		{"admin", "/api/v1/admin/users", "admin-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &listService{}
+			handler := NewHandler(service)
+
+			// Same groups as App.SetupRoutes
+			router := gin.New()
+			v1 := router.Group("/api/v1")
+			handler.RegisAuthRoutes(v1.Group("", middleware.Auth(auth.StaticKeys("jwt-secret"))))
+			handler.RegisAdminRoutes(v1.Group("/admin", middleware.AdminKey(secrets.NewValue("server.admin_api_key", "admin-key", nil))))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			if tt.adminKey != "" {
+				req.Header.Set("X-Admin-Key", tt.adminKey)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if listed := service.limit != 0; listed != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("users listed = %v with status %d", listed, rec.Code)
+			}
+		})
+	}
+}
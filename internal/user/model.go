@@ -1,8 +1,18 @@
 package user
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type User struct {
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"-"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Username  string         `json:"username"`
+	Email     string         `json:"email"`
+	Password  string         `json:"-"`
+	Locale    string         `json:"locale"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -2,31 +2,84 @@ package user
 
 import (
 	"context"
+	"errors"
 
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	Create(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id uint) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]User, error)
+	// List returns up to limit users with an ID greater than afterID, ordered by ID
+	List(ctx context.Context, afterID uint, limit int) ([]User, error)
+	// UpdateLocale sets the preferred locale, returning false when the user does not exist
+	UpdateLocale(ctx context.Context, id uint, locale string) (bool, error)
+	WithTx(tx *gorm.DB) Repository
 }
 
 type repository struct {
-	// db *gorm.DB // contoh jika pakai GORM
+	db *gorm.DB
 }
 
 func NewRepository(db *gorm.DB) Repository {
 	return &repository{
-		// db: db,
+		db: db,
 	}
 }
 
+// WithTx returns a repository bound to an open transaction
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
 func (r *repository) Create(ctx context.Context, user *User) error {
-	// Implementasi simpan user ke DB
-	return nil
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// FindByID returns nil without error when the user does not exist
+func (r *repository) FindByID(ctx context.Context, id uint) (*User, error) {
+	var user User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
+// FindByEmail returns nil without error when the user does not exist
 func (r *repository) FindByEmail(ctx context.Context, email string) (*User, error) {
-	// Implementasi cari user by email
-	return nil, nil
+	var user User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) FindByIDs(ctx context.Context, ids []uint) ([]User, error) {
+	var users []User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *repository) List(ctx context.Context, afterID uint, limit int) ([]User, error) {
+	var users []User
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+func (r *repository) UpdateLocale(ctx context.Context, id uint, locale string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Update("locale", locale)
+	return result.RowsAffected > 0, result.Error
 }
@@ -2,35 +2,231 @@ package user
 
 import (
 	"context"
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/database"
 	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/outbox"
+	"go-user-service/internal/pkg/tracing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// usernameConstraint is the unique index on users.username
+const usernameConstraint = "users_username_key"
+
 type Service interface {
 	Register(ctx context.Context, req CreateUserRequest) (*UserResponse, *errors.AppError)
+	GetByID(ctx context.Context, id uint) (*UserResponse, *errors.AppError)
+	GetByEmail(ctx context.Context, email string) (*UserResponse, *errors.AppError)
+	List(ctx context.Context, afterID uint, limit int) (*ListUsersResult, *errors.AppError)
+	// BatchGet returns the users found among ids, in ID order
+	BatchGet(ctx context.Context, ids []uint) ([]UserResponse, *errors.AppError)
+	UpdateLocale(ctx context.Context, id uint, locale string) (*UserResponse, *errors.AppError)
+	// Locale returns the preferred locale of a user, empty when none is set
+	Locale(ctx context.Context, id uint) (string, *errors.AppError)
 }
 
 type service struct {
-	repo Repository
+	db     *gorm.DB
+	repo   Repository
+	outbox *outbox.Store
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(db *gorm.DB, repo Repository, outbox *outbox.Store) Service {
+	return &service{db: db, repo: repo, outbox: outbox}
 }
 
 func (s *service) Register(ctx context.Context, req CreateUserRequest) (*UserResponse, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.Register")
+	defer span.End()
+	// The email check and the returned user must not come from a lagging replica
+	ctx = database.WithPrimary(ctx)
+
+	existing, err := s.repo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to check email")
+	}
+	if existing != nil {
+		return nil, errors.New(errors.ErrCodeAlreadyExists, "Email is already registered")
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		// Binding counts characters, bcrypt counts bytes
+		return nil, errors.Wrap(err, errors.ErrCodeValidation, "Password is too long")
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "Failed to hash password")
+	}
+
 	user := &User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: req.Password, // hash password di sini
+		Password: string(hashed),
+		Locale:   req.Locale,
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
+	// The user row and its UserRegistered event commit together
+	err = database.WithTransaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(ctx, user); err != nil {
+			return err
+		}
+
+		event, err := domain.NewUserEvent(domain.UserRegistered, user.ID, domain.UserRegisteredPayload{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, tx, event)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		// A taken username, or an email registered concurrently, loses on a unique index
+		if errors.IsErrorCode(errors.Classify(err), errors.ErrCodeAlreadyExists) {
+			if violatedConstraint(err) == usernameConstraint {
+				return nil, errors.Wrap(err, errors.ErrCodeAlreadyExists, "Username is already taken")
+			}
+			return nil, errors.Wrap(err, errors.ErrCodeAlreadyExists, "Email is already registered")
+		}
 		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to create user")
 	}
 
-	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-	}, nil
+	return toUserResponse(user), nil
+}
+
+func (s *service) GetByID(ctx context.Context, id uint) (*UserResponse, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.GetByID")
+	defer span.End()
+
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get user")
+	}
+	if user == nil {
+		return nil, errors.New(errors.ErrCodeNotFound, "User not found")
+	}
+	return toUserResponse(user), nil
+}
+
+func (s *service) GetByEmail(ctx context.Context, email string) (*UserResponse, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.GetByEmail")
+	defer span.End()
+
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get user")
+	}
+	if user == nil {
+		return nil, errors.New(errors.ErrCodeNotFound, "User not found")
+	}
+	return toUserResponse(user), nil
+}
+
+func (s *service) List(ctx context.Context, afterID uint, limit int) (*ListUsersResult, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.List")
+	defer span.End()
+
+	// Fetch one extra row to know whether another page exists
+	users, err := s.repo.List(ctx, afterID, limit+1)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to list users")
+	}
+
+	result := &ListUsersResult{Users: make([]UserResponse, 0, limit)}
+	for i := range users {
+		if i == limit {
+			result.NextAfterID = users[i-1].ID
+			break
+		}
+		result.Users = append(result.Users, *toUserResponse(&users[i]))
+	}
+	return result, nil
+}
+
+func (s *service) BatchGet(ctx context.Context, ids []uint) ([]UserResponse, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.BatchGet")
+	defer span.End()
+
+	users, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get users")
+	}
+
+	result := make([]UserResponse, 0, len(users))
+	for i := range users {
+		result = append(result, *toUserResponse(&users[i]))
+	}
+	return result, nil
+}
+
+func (s *service) UpdateLocale(ctx context.Context, id uint, locale string) (*UserResponse, *errors.AppError) {
+	ctx, span := tracing.Start(ctx, "user.Service.UpdateLocale")
+	defer span.End()
+	ctx = database.WithPrimary(ctx)
+
+	// The new locale and its UserProfileUpdated event commit together,
+	// setting the current locale again changes nothing and emits no event
+	found := false
+	err := database.WithTransaction(s.db.WithContext(ctx), func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		user, err := repo.FindByID(ctx, id)
+		if err != nil || user == nil {
+			return err
+		}
+		found = true
+		if user.Locale == locale {
+			return nil
+		}
+
+		if _, err := repo.UpdateLocale(ctx, id, locale); err != nil {
+			return err
+		}
+		event, err := domain.NewUserEvent(domain.UserProfileUpdated, id, domain.UserProfileUpdatedPayload{
+			UserID:        id,
+			ChangedFields: []string{"locale"},
+		})
+		if err != nil {
+			return err
+		}
+		return s.outbox.Add(ctx, tx, event)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to update locale")
+	}
+	if !found {
+		return nil, errors.New(errors.ErrCodeNotFound, "User not found")
+	}
+	return s.GetByID(ctx, id)
+}
+
+func (s *service) Locale(ctx context.Context, id uint) (string, *errors.AppError) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get user")
+	}
+	if user == nil {
+		return "", nil
+	}
+	return user.Locale, nil
+}
+
+// violatedConstraint returns the constraint named by a Postgres error in err, if any
+func violatedConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
 }
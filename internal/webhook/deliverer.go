@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/events"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/worker"
+)
+
+// Job type and queue of webhook deliveries
+const (
+	DeliverJobType = "webhook.deliver"
+	Queue          = "webhooks"
+)
+
+// ErrEndpointDisabled is returned when delivering to a disabled or deleted endpoint
+var ErrEndpointDisabled = errors.New("webhook endpoint is disabled")
+
+// DeliveryJob is the payload of a webhook.deliver job
+type DeliveryJob struct {
+	EndpointID uint            `json:"endpoint_id"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Enqueuer puts delivery jobs on the worker queue, see worker.Client
+type Enqueuer interface {
+	Enqueue(ctx context.Context, queue, jobType string, payload interface{}, opts ...worker.EnqueueOption) (*worker.Job, error)
+}
+
+// DelivererOptions configures webhook delivery
+type DelivererOptions struct {
+	Timeout time.Duration
+	// DisableAfter consecutive failed attempts disables the endpoint
+	DisableAfter int
+	// MaxResponseBody bounds how much of the response is stored in the delivery log
+	MaxResponseBody int64
+	UserAgent       string
+}
+
+// Deliverer fans events out to subscribed endpoints and performs the signed requests
+type Deliverer struct {
+	repo       Repository
+	enqueuer   Enqueuer
+	maxRetries int
+	client     *http.Client
+	opts       DelivererOptions
+	logger     *logger.Logger
+}
+
+// NewDeliverer creates a new webhook deliverer
+func NewDeliverer(repo Repository, enqueuer Enqueuer, maxRetries int, opts DelivererOptions, logger *logger.Logger) *Deliverer {
+	return &Deliverer{
+		repo:       repo,
+		enqueuer:   enqueuer,
+		maxRetries: maxRetries,
+		client:     &http.Client{Timeout: opts.Timeout},
+		opts:       opts,
+		logger:     logger,
+	}
+}
+
+// HandleEvent is an events.Handler enqueuing one delivery per subscribed endpoint
+func (d *Deliverer) HandleEvent(ctx context.Context, msg *events.Message) error {
+	var event domain.Event
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return err
+	}
+
+	endpoints, err := d.repo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribed(string(event.Type)) {
+			continue
+		}
+		_, err := d.enqueuer.Enqueue(ctx, Queue, DeliverJobType, DeliveryJob{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			EventType:  string(event.Type),
+			Body:       msg.Payload,
+		},
+			worker.WithMaxRetries(d.maxRetries),
+			// A redelivered event must not reach the endpoint twice
+			worker.WithUniqueKey(fmt.Sprintf("webhook:%d:%s", endpoint.ID, event.ID)),
+		)
+		if err != nil && !errors.Is(err, worker.ErrDuplicateJob) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handle runs one webhook.deliver job. Failures are returned so the worker
+// retries them with exponential backoff.
+func (d *Deliverer) Handle(ctx context.Context, job *worker.Job) error {
+	var payload DeliveryJob
+	if err := job.Decode(&payload); err != nil {
+		return worker.Permanent(err)
+	}
+
+	endpoint, err := d.repo.FindByID(ctx, payload.EndpointID)
+	if err != nil {
+		return err
+	}
+	if endpoint == nil || !endpoint.Enabled {
+		return worker.Permanent(ErrEndpointDisabled)
+	}
+
+	delivery := d.send(ctx, endpoint, payload, job.Attempt+1)
+	if err := d.repo.CreateDelivery(ctx, delivery); err != nil {
+		d.logger.LogError(err, "webhook_delivery_log", map[string]interface{}{"endpoint_id": endpoint.ID})
+	}
+
+	updated, err := d.repo.RecordResult(ctx, endpoint.ID, delivery.Success, d.opts.DisableAfter)
+	if err != nil {
+		d.logger.LogError(err, "webhook_record_result", map[string]interface{}{"endpoint_id": endpoint.ID})
+	} else if updated != nil && !updated.Enabled && endpoint.Enabled {
+		d.logger.LogBusinessEvent("webhook_endpoint_disabled", "", map[string]interface{}{
+			"endpoint_id":   endpoint.ID,
+			"url":           endpoint.URL,
+			"failure_count": updated.FailureCount,
+		})
+	}
+
+	if delivery.Success {
+		return nil
+	}
+	if updated != nil && !updated.Enabled {
+		return worker.Permanent(ErrEndpointDisabled)
+	}
+	return errors.New(delivery.Error)
+}
+
+// send performs one signed POST and captures the exchange
+func (d *Deliverer) send(ctx context.Context, endpoint *Endpoint, payload DeliveryJob, attempt int) *Delivery {
+	now := time.Now()
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"User-Agent":    d.opts.UserAgent,
+		HeaderID:        payload.EventID,
+		HeaderEvent:     payload.EventType,
+		HeaderTimestamp: strconv.FormatInt(now.Unix(), 10),
+		HeaderSignature: Sign(endpoint.Secret, now, payload.Body),
+	}
+
+	delivery := &Delivery{
+		EndpointID:     endpoint.ID,
+		EventID:        payload.EventID,
+		EventType:      payload.EventType,
+		Attempt:        attempt,
+		RequestURL:     endpoint.URL,
+		RequestHeaders: redactSignature(headers),
+		RequestBody:    string(payload.Body),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload.Body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(now).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, d.opts.MaxResponseBody))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(body)
+	delivery.ResponseHeaders = make(Headers, len(resp.Header))
+	for key := range resp.Header {
+		delivery.ResponseHeaders[key] = resp.Header.Get(key)
+	}
+
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// redactSignature keeps the signature out of the stored request, it is
+// derived from the secret and could be replayed within the tolerance window
+func redactSignature(headers map[string]string) Headers {
+	stored := make(Headers, len(headers))
+	for key, value := range headers {
+		if strings.EqualFold(key, HeaderSignature) {
+			value = "[REDACTED]"
+		}
+		stored[key] = value
+	}
+	return stored
+}
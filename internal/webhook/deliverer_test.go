@@ -0,0 +1,359 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/events"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/worker"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// memoryRepository keeps endpoints and deliveries in memory, RecordResult
+// follows the single UPDATE of the gorm repository
+type memoryRepository struct {
+	mu         sync.Mutex
+	endpoints  map[uint]*Endpoint
+	deliveries []Delivery
+}
+
+func newMemoryRepository(endpoints ...Endpoint) *memoryRepository {
+	r := &memoryRepository{endpoints: make(map[uint]*Endpoint)}
+	for i := range endpoints {
+		endpoint := endpoints[i]
+		r.endpoints[endpoint.ID] = &endpoint
+	}
+	return r
+}
+
+func (r *memoryRepository) Create(ctx context.Context, endpoint *Endpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	endpoint.ID = uint(len(r.endpoints) + 1)
+	stored := *endpoint
+	r.endpoints[endpoint.ID] = &stored
+	return nil
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id uint) (*Endpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	endpoint, ok := r.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *endpoint
+	return &found, nil
+}
+
+func (r *memoryRepository) List(ctx context.Context) ([]Endpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var endpoints []Endpoint
+	for _, endpoint := range r.endpoints {
+		endpoints = append(endpoints, *endpoint)
+	}
+	return endpoints, nil
+}
+
+func (r *memoryRepository) ListEnabled(ctx context.Context) ([]Endpoint, error) {
+	all, _ := r.List(ctx)
+	var endpoints []Endpoint
+	for _, endpoint := range all {
+		if endpoint.Enabled {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, endpoint *Endpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *endpoint
+	r.endpoints[endpoint.ID] = &stored
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.endpoints[id]
+	delete(r.endpoints, id)
+	return ok, nil
+}
+
+func (r *memoryRepository) RecordResult(ctx context.Context, id uint, success bool, disableAfter int) (*Endpoint, error) {
+	r.mu.Lock()
+	endpoint, ok := r.endpoints[id]
+	if ok {
+		if success {
+			endpoint.FailureCount = 0
+		} else {
+			endpoint.FailureCount++
+			if endpoint.Enabled && endpoint.FailureCount >= disableAfter {
+				now := time.Now()
+				endpoint.Enabled = false
+				endpoint.DisabledAt = &now
+				endpoint.DisabledReason = "too many consecutive failed deliveries"
+			}
+		}
+	}
+	r.mu.Unlock()
+	return r.FindByID(ctx, id)
+}
+
+func (r *memoryRepository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.ID = uint(len(r.deliveries) + 1)
+	r.deliveries = append(r.deliveries, *delivery)
+	return nil
+}
+
+func (r *memoryRepository) FindDelivery(ctx context.Context, id uint) (*Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id {
+			return &delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) ListDeliveries(ctx context.Context, endpointID uint, limit, offset int) ([]Delivery, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []Delivery
+	for _, delivery := range r.deliveries {
+		if delivery.EndpointID == endpointID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, int64(len(deliveries)), nil
+}
+
+// Deliveries returns every logged delivery attempt
+func (r *memoryRepository) Deliveries() []Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Delivery(nil), r.deliveries...)
+}
+
+// receiver is an endpoint answering every request with status
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	times    []time.Time
+	status   int
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	t.Helper()
+	rec := &receiver{status: status}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, r)
+		rec.bodies = append(rec.bodies, body)
+		rec.times = append(rec.times, time.Now())
+		rec.mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"ok":false}`))
+	}))
+	t.Cleanup(server.Close)
+	return rec, server
+}
+
+func (r *receiver) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func newTestDeliverer(repo Repository, enqueuer Enqueuer, maxRetries, disableAfter int) *Deliverer {
+	return NewDeliverer(repo, enqueuer, maxRetries, DelivererOptions{
+		Timeout:         5 * time.Second,
+		DisableAfter:    disableAfter,
+		MaxResponseBody: 1024,
+		UserAgent:       "user-service-webhooks/test",
+	}, logger.New("panic", "test"))
+}
+
+func deliveryJob(t *testing.T, endpointID uint, attempt int) *worker.Job {
+	t.Helper()
+	job, err := worker.NewJob(context.Background(), DeliverJobType, DeliveryJob{
+		EndpointID: endpointID,
+		EventID:    "evt-1",
+		EventType:  "user.registered",
+		Body:       json.RawMessage(`{"id":"evt-1"}`),
+	})
+	if err != nil {
+		t.Fatalf("NewJob() error = %v", err)
+	}
+	job.Attempt = attempt
+	return job
+}
+
+func TestHandleSendsSignedRequest(t *testing.T) {
+	rec, server := newReceiver(t, http.StatusOK)
+	repo := newMemoryRepository(Endpoint{ID: 1, URL: server.URL, Secret: "whsec_test", Enabled: true, FailureCount: 2})
+	d := newTestDeliverer(repo, nil, 3, 5)
+
+	if err := d.Handle(context.Background(), deliveryJob(t, 1, 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if rec.Count() != 1 {
+		t.Fatalf("endpoint received %d requests, want 1", rec.Count())
+	}
+	req, body := rec.requests[0], rec.bodies[0]
+	if req.Header.Get(HeaderID) != "evt-1" || req.Header.Get(HeaderEvent) != "user.registered" {
+		t.Errorf("headers = %v, want the event ID and type", req.Header)
+	}
+	err := Verify("whsec_test", req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, time.Minute)
+	if err != nil {
+		t.Errorf("Verify() error = %v, want the request signed with the endpoint secret", err)
+	}
+
+	deliveries := repo.Deliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("logged %d deliveries, want 1", len(deliveries))
+	}
+	logged := deliveries[0]
+	if !logged.Success || logged.Attempt != 1 || logged.ResponseStatus != http.StatusOK {
+		t.Errorf("delivery = %+v, want a successful first attempt", logged)
+	}
+	if logged.RequestHeaders[HeaderSignature] != "[REDACTED]" {
+		t.Errorf("stored signature = %q, want it redacted", logged.RequestHeaders[HeaderSignature])
+	}
+
+	endpoint, _ := repo.FindByID(context.Background(), 1)
+	if endpoint.FailureCount != 0 {
+		t.Errorf("FailureCount = %d, want a success to reset it", endpoint.FailureCount)
+	}
+}
+
+func TestHandleDisablesFailingEndpoint(t *testing.T) {
+	const disableAfter = 3
+	rec, server := newReceiver(t, http.StatusInternalServerError)
+	repo := newMemoryRepository(Endpoint{ID: 1, URL: server.URL, Secret: "whsec_test", Enabled: true})
+	d := newTestDeliverer(repo, nil, 10, disableAfter)
+
+	for attempt := 0; attempt < disableAfter; attempt++ {
+		err := d.Handle(context.Background(), deliveryJob(t, 1, attempt))
+		if err == nil {
+			t.Fatalf("attempt %d: Handle() succeeded against a failing endpoint", attempt+1)
+		}
+
+		endpoint, _ := repo.FindByID(context.Background(), 1)
+		if endpoint.FailureCount != attempt+1 {
+			t.Errorf("attempt %d: FailureCount = %d, want %d", attempt+1, endpoint.FailureCount, attempt+1)
+		}
+		last := attempt == disableAfter-1
+		if endpoint.Enabled == last {
+			t.Errorf("attempt %d: Enabled = %v, want %v", attempt+1, endpoint.Enabled, !last)
+		}
+		// Failures are retried until the endpoint is disabled
+		if worker.IsPermanent(err) != last {
+			t.Errorf("attempt %d: IsPermanent(%v) = %v, want %v", attempt+1, err, !last, last)
+		}
+		if last && !errors.Is(err, ErrEndpointDisabled) {
+			t.Errorf("attempt %d: error = %v, want ErrEndpointDisabled", attempt+1, err)
+		}
+	}
+
+	// Retries left on the queue stop without calling the endpoint
+	err := d.Handle(context.Background(), deliveryJob(t, 1, disableAfter))
+	if !worker.IsPermanent(err) || !errors.Is(err, ErrEndpointDisabled) {
+		t.Errorf("Handle() error = %v, want a permanent ErrEndpointDisabled", err)
+	}
+	if rec.Count() != disableAfter {
+		t.Errorf("endpoint received %d requests, want %d", rec.Count(), disableAfter)
+	}
+
+	endpoint, _ := repo.FindByID(context.Background(), 1)
+	if endpoint.DisabledAt == nil || endpoint.DisabledReason == "" {
+		t.Errorf("endpoint = %+v, want the disable time and reason", endpoint)
+	}
+}
+
+func TestRetrySchedule(t *testing.T) {
+	const maxRetries = 3
+	rec, server := newReceiver(t, http.StatusServiceUnavailable)
+	repo := newMemoryRepository(Endpoint{
+		ID: 1, URL: server.URL, Secret: "whsec_test", Enabled: true, EventTypes: StringList{AllEvents},
+	})
+
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	queue := worker.NewRedisQueue(client)
+
+	opts := worker.DefaultOptions()
+	opts.PollInterval = 5 * time.Millisecond
+	opts.PromoteInterval = 5 * time.Millisecond
+	opts.RetryBackoff = 50 * time.Millisecond
+	opts.MaxRetryBackoff = 100 * time.Millisecond
+	runtime := worker.New(queue, logger.New("panic", "test"), opts)
+	runtime.AddQueue(worker.QueueConfig{Name: Queue, Concurrency: 1, Timeout: 5 * time.Second})
+
+	d := newTestDeliverer(repo, worker.NewClient(queue), maxRetries, 100)
+	runtime.Register(DeliverJobType, Queue, maxRetries, d.Handle)
+
+	event, err := domain.NewUserEvent(domain.UserRegistered, 7, domain.UserRegisteredPayload{UserID: 7})
+	if err != nil {
+		t.Fatalf("NewUserEvent() error = %v", err)
+	}
+	payload, _ := json.Marshal(event)
+	if err := d.HandleEvent(context.Background(), &events.Message{ID: event.ID, Payload: payload}); err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+
+	runtime.Start(context.Background())
+	defer runtime.Shutdown(context.Background())
+
+	var dead []worker.Job
+	deadline := time.Now().Add(10 * time.Second)
+	for len(dead) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if dead, err = queue.DeadJobs(context.Background(), Queue, 10); err != nil {
+			t.Fatalf("DeadJobs() error = %v", err)
+		}
+	}
+	if len(dead) != 1 {
+		t.Fatalf("DeadJobs() = %v, want the delivery dead-lettered after its retries", dead)
+	}
+
+	// The first attempt and maxRetries retries, each logged with its number
+	deliveries := repo.Deliveries()
+	if rec.Count() != maxRetries+1 || len(deliveries) != maxRetries+1 {
+		t.Fatalf("endpoint received %d requests and %d were logged, want %d", rec.Count(), len(deliveries), maxRetries+1)
+	}
+	for i, delivery := range deliveries {
+		if delivery.Attempt != i+1 {
+			t.Errorf("delivery %d Attempt = %d, want %d", i, delivery.Attempt, i+1)
+		}
+	}
+
+	// Backoff doubles from RetryBackoff and is capped at MaxRetryBackoff
+	want := []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}
+	for i, min := range want {
+		if gap := rec.times[i+1].Sub(rec.times[i]); gap < min {
+			t.Errorf("retry %d came after %s, want at least %s", i+1, gap, min)
+		}
+	}
+}
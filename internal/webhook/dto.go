@@ -0,0 +1,29 @@
+package webhook
+
+type CreateEndpointRequest struct {
+	URL         string   `json:"url" binding:"required,url"`
+	Description string   `json:"description" binding:"max=255"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+}
+
+type UpdateEndpointRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,url"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+type ListDeliveriesRequest struct {
+	Page    int `form:"page"`
+	PerPage int `form:"per_page"`
+}
+
+// EndpointWithSecret is returned once on creation so the partner can verify signatures
+type EndpointWithSecret struct {
+	Endpoint
+	Secret string `json:"secret"`
+}
+
+type RedeliverResponse struct {
+	JobID string `json:"job_id"`
+}
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisRoutes registers the webhook management routes, rg must be restricted to administrators
+func (h *Handler) RegisRoutes(rg *gin.RouterGroup) {
+	webhooks := rg.Group("/webhooks")
+	webhooks.POST("", h.Create)
+	webhooks.GET("", h.List)
+	webhooks.GET("/:id", h.Get)
+	webhooks.PATCH("/:id", h.Update)
+	webhooks.DELETE("/:id", h.Delete)
+	webhooks.GET("/:id/deliveries", h.ListDeliveries)
+	webhooks.POST("/deliveries/:delivery_id/redeliver", h.Redeliver)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid webhook endpoint"))
+		return
+	}
+
+	endpoint, appErr := h.service.Create(c.Request.Context(), req)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.Created(c, endpoint)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	endpoints, appErr := h.service.List(c.Request.Context())
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, endpoints)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	endpoint, appErr := h.service.Get(c.Request.Context(), id)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, endpoint)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid webhook endpoint"))
+		return
+	}
+
+	endpoint, appErr := h.service.Update(c.Request.Context(), id, req)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.OK(c, endpoint)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if appErr := h.service.Delete(c.Request.Context(), id); appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ListDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, errors.Wrap(err, errors.ErrCodeValidation, "Invalid query parameters"))
+		return
+	}
+
+	deliveries, meta, appErr := h.service.ListDeliveries(c.Request.Context(), id, req)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.JSONWithMeta(c, http.StatusOK, deliveries, meta)
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	id, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	result, appErr := h.service.Redeliver(c.Request.Context(), id)
+	if appErr != nil {
+		response.Error(c, appErr)
+		return
+	}
+	response.JSON(c, http.StatusAccepted, result)
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		response.Error(c, errors.New(errors.ErrCodeValidation, "Invalid ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AllEvents subscribes an endpoint to every event type
+const AllEvents = "*"
+
+// StringList is a JSONB array of strings
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// Headers is a JSONB object of HTTP headers
+type Headers map[string]string
+
+// Value implements driver.Valuer
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan implements sql.Scanner
+func (h *Headers) Scan(value interface{}) error {
+	return scanJSON(value, h)
+}
+
+func scanJSON(value interface{}, v interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", value)
+	}
+}
+
+type Endpoint struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	URL            string     `json:"url"`
+	Description    string     `json:"description"`
+	Secret         string     `json:"-"`
+	EventTypes     StringList `json:"event_types" gorm:"type:jsonb"`
+	Enabled        bool       `json:"enabled"`
+	FailureCount   int        `json:"failure_count"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName implements gorm.Tabler
+func (Endpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Subscribed reports whether the endpoint receives eventType
+func (e *Endpoint) Subscribed(eventType string) bool {
+	return slices.Contains(e.EventTypes, AllEvents) || slices.Contains(e.EventTypes, eventType)
+}
+
+// Delivery is one attempt to deliver an event to an endpoint
+type Delivery struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EndpointID      uint      `json:"endpoint_id"`
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Attempt         int       `json:"attempt"`
+	RequestURL      string    `json:"request_url"`
+	RequestHeaders  Headers   `json:"request_headers" gorm:"type:jsonb"`
+	RequestBody     string    `json:"request_body"`
+	ResponseStatus  int       `json:"response_status"`
+	ResponseHeaders Headers   `json:"response_headers" gorm:"type:jsonb"`
+	ResponseBody    string    `json:"response_body"`
+	Error           string    `json:"error,omitempty"`
+	Success         bool      `json:"success"`
+	DurationMs      int64     `json:"duration_ms"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName implements gorm.Tabler
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
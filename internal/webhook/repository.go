@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, endpoint *Endpoint) error
+	FindByID(ctx context.Context, id uint) (*Endpoint, error)
+	List(ctx context.Context) ([]Endpoint, error)
+	ListEnabled(ctx context.Context) ([]Endpoint, error)
+	Update(ctx context.Context, endpoint *Endpoint) error
+	Delete(ctx context.Context, id uint) (bool, error)
+
+	// RecordResult resets the failure count on success, or increments it and
+	// disables the endpoint once it reaches disableAfter. It returns the endpoint
+	// state after the update.
+	RecordResult(ctx context.Context, id uint, success bool, disableAfter int) (*Endpoint, error)
+
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	FindDelivery(ctx context.Context, id uint) (*Delivery, error)
+	ListDeliveries(ctx context.Context, endpointID uint, limit, offset int) ([]Delivery, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, endpoint *Endpoint) error {
+	return r.db.WithContext(ctx).Create(endpoint).Error
+}
+
+// FindByID returns nil without error when the endpoint does not exist
+func (r *repository) FindByID(ctx context.Context, id uint) (*Endpoint, error) {
+	var endpoint Endpoint
+	err := r.db.WithContext(ctx).First(&endpoint, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *repository) List(ctx context.Context) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := r.db.WithContext(ctx).Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *repository) ListEnabled(ctx context.Context) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := r.db.WithContext(ctx).Where("enabled").Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *repository) Update(ctx context.Context, endpoint *Endpoint) error {
+	return r.db.WithContext(ctx).Save(endpoint).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&Endpoint{}, id)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) RecordResult(ctx context.Context, id uint, success bool, disableAfter int) (*Endpoint, error) {
+	var endpoint Endpoint
+	now := time.Now()
+
+	query := r.db.WithContext(ctx).Model(&endpoint).Where("id = ?", id)
+	var err error
+	if success {
+		err = query.Updates(map[string]interface{}{"failure_count": 0, "updated_at": now}).Error
+	} else {
+		// A single statement keeps concurrent deliveries from losing increments
+		err = query.Updates(map[string]interface{}{
+			"failure_count":   gorm.Expr("failure_count + 1"),
+			"enabled":         gorm.Expr("enabled AND failure_count + 1 < ?", disableAfter),
+			"disabled_at":     gorm.Expr("CASE WHEN enabled AND failure_count + 1 >= ? THEN ? ELSE disabled_at END", disableAfter, now),
+			"disabled_reason": gorm.Expr("CASE WHEN enabled AND failure_count + 1 >= ? THEN ? ELSE disabled_reason END", disableAfter, "too many consecutive failed deliveries"),
+			"updated_at":      now,
+		}).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *repository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// FindDelivery returns nil without error when the delivery does not exist
+func (r *repository) FindDelivery(ctx context.Context, id uint) (*Delivery, error) {
+	var delivery Delivery
+	err := r.db.WithContext(ctx).First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *repository) ListDeliveries(ctx context.Context, endpointID uint, limit, offset int) ([]Delivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&Delivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []Delivery
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-user-service/internal/domain"
+	"go-user-service/internal/pkg/errors"
+	"go-user-service/internal/pkg/response"
+	"go-user-service/internal/worker"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+type Service interface {
+	Create(ctx context.Context, req CreateEndpointRequest) (*EndpointWithSecret, *errors.AppError)
+	List(ctx context.Context) ([]Endpoint, *errors.AppError)
+	Get(ctx context.Context, id uint) (*Endpoint, *errors.AppError)
+	Update(ctx context.Context, id uint, req UpdateEndpointRequest) (*Endpoint, *errors.AppError)
+	Delete(ctx context.Context, id uint) *errors.AppError
+	ListDeliveries(ctx context.Context, endpointID uint, req ListDeliveriesRequest) ([]Delivery, *response.Meta, *errors.AppError)
+	Redeliver(ctx context.Context, deliveryID uint) (*RedeliverResponse, *errors.AppError)
+}
+
+type service struct {
+	repo       Repository
+	enqueuer   Enqueuer
+	maxRetries int
+}
+
+func NewService(repo Repository, enqueuer Enqueuer, maxRetries int) Service {
+	return &service{repo: repo, enqueuer: enqueuer, maxRetries: maxRetries}
+}
+
+func (s *service) Create(ctx context.Context, req CreateEndpointRequest) (*EndpointWithSecret, *errors.AppError) {
+	if appErr := validateEventTypes(req.EventTypes); appErr != nil {
+		return nil, appErr
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "Failed to generate webhook secret")
+	}
+
+	endpoint := &Endpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      secret,
+		EventTypes:  req.EventTypes,
+		Enabled:     true,
+	}
+	if err := s.repo.Create(ctx, endpoint); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to create webhook endpoint")
+	}
+
+	return &EndpointWithSecret{Endpoint: *endpoint, Secret: secret}, nil
+}
+
+func (s *service) List(ctx context.Context) ([]Endpoint, *errors.AppError) {
+	endpoints, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to list webhook endpoints")
+	}
+	return endpoints, nil
+}
+
+func (s *service) Get(ctx context.Context, id uint) (*Endpoint, *errors.AppError) {
+	endpoint, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get webhook endpoint")
+	}
+	if endpoint == nil {
+		return nil, errors.New(errors.ErrCodeNotFound, "Webhook endpoint not found")
+	}
+	return endpoint, nil
+}
+
+func (s *service) Update(ctx context.Context, id uint, req UpdateEndpointRequest) (*Endpoint, *errors.AppError) {
+	endpoint, appErr := s.Get(ctx, id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		if appErr := validateEventTypes(req.EventTypes); appErr != nil {
+			return nil, appErr
+		}
+		endpoint.EventTypes = req.EventTypes
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+		if endpoint.Enabled {
+			// Re-enabling gives the endpoint a fresh failure budget
+			endpoint.FailureCount = 0
+			endpoint.DisabledAt = nil
+			endpoint.DisabledReason = ""
+		} else if endpoint.DisabledAt == nil {
+			now := time.Now()
+			endpoint.DisabledAt = &now
+			endpoint.DisabledReason = "disabled by an administrator"
+		}
+	}
+
+	if err := s.repo.Update(ctx, endpoint); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to update webhook endpoint")
+	}
+	return endpoint, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uint) *errors.AppError {
+	found, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabase, "Failed to delete webhook endpoint")
+	}
+	if !found {
+		return errors.New(errors.ErrCodeNotFound, "Webhook endpoint not found")
+	}
+	return nil
+}
+
+func (s *service) ListDeliveries(ctx context.Context, endpointID uint, req ListDeliveriesRequest) ([]Delivery, *response.Meta, *errors.AppError) {
+	if _, appErr := s.Get(ctx, endpointID); appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PerPage < 1 {
+		req.PerPage = defaultPerPage
+	}
+	if req.PerPage > maxPerPage {
+		req.PerPage = maxPerPage
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, endpointID, req.PerPage, (req.Page-1)*req.PerPage)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to list webhook deliveries")
+	}
+
+	return deliveries, &response.Meta{
+		Page:       req.Page,
+		PerPage:    req.PerPage,
+		Total:      int(total),
+		TotalPages: int((total + int64(req.PerPage) - 1) / int64(req.PerPage)),
+	}, nil
+}
+
+// Redeliver sends the stored request body of a delivery again as a new job
+func (s *service) Redeliver(ctx context.Context, deliveryID uint) (*RedeliverResponse, *errors.AppError) {
+	delivery, err := s.repo.FindDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabase, "Failed to get webhook delivery")
+	}
+	if delivery == nil {
+		return nil, errors.New(errors.ErrCodeNotFound, "Webhook delivery not found")
+	}
+
+	endpoint, appErr := s.Get(ctx, delivery.EndpointID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !endpoint.Enabled {
+		return nil, errors.New(errors.ErrCodeValidation, "Webhook endpoint is disabled, enable it before redelivering")
+	}
+
+	job, err := s.enqueuer.Enqueue(ctx, Queue, DeliverJobType, DeliveryJob{
+		EndpointID: delivery.EndpointID,
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Body:       []byte(delivery.RequestBody),
+	}, worker.WithMaxRetries(s.maxRetries))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "Failed to enqueue webhook redelivery")
+	}
+
+	return &RedeliverResponse{JobID: job.ID}, nil
+}
+
+func validateEventTypes(eventTypes []string) *errors.AppError {
+	for _, eventType := range eventTypes {
+		if eventType == AllEvents || slices.Contains(domain.EventTypes, domain.EventType(eventType)) {
+			continue
+		}
+		return errors.New(errors.ErrCodeValidation, fmt.Sprintf("Unknown event type %q", eventType))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	sentAt := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"user.registered"}`)
+
+	got := Sign("whsec_test", sentAt, body)
+
+	if !regexp.MustCompile(`^sha256=[0-9a-f]{64}$`).MatchString(got) {
+		t.Fatalf("Sign() = %q, want sha256=<hex>", got)
+	}
+	// Receivers compute HMAC-SHA256 over "<unix timestamp>.<body>"
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+
+	if Sign("whsec_test", sentAt.Add(time.Second), body) == got {
+		t.Error("Sign() does not cover the timestamp")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	unix := func(at time.Time) string { return strconv.FormatInt(at.Unix(), 10) }
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		want      error
+	}{
+		{
+			name:      "valid",
+			timestamp: unix(now),
+			signature: Sign(secret, now, body),
+			body:      body,
+		},
+		{
+			name:      "tampered body",
+			timestamp: unix(now),
+			signature: Sign(secret, now, body),
+			body:      []byte(`{"id":"2"}`),
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "other secret",
+			timestamp: unix(now),
+			signature: Sign("whsec_other", now, body),
+			body:      body,
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "missing scheme",
+			timestamp: unix(now),
+			signature: Sign(secret, now, body)[len(signaturePrefix):],
+			body:      body,
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "replayed with a fresh timestamp",
+			timestamp: unix(now),
+			signature: Sign(secret, now.Add(-time.Hour), body),
+			body:      body,
+			want:      ErrInvalidSignature,
+		},
+		{
+			name:      "too old",
+			timestamp: unix(now.Add(-10 * time.Minute)),
+			signature: Sign(secret, now.Add(-10*time.Minute), body),
+			body:      body,
+			want:      ErrExpiredTimestamp,
+		},
+		{
+			name:      "from the future",
+			timestamp: unix(now.Add(10 * time.Minute)),
+			signature: Sign(secret, now.Add(10*time.Minute), body),
+			body:      body,
+			want:      ErrExpiredTimestamp,
+		},
+		{
+			name:      "unparsable timestamp",
+			timestamp: "yesterday",
+			signature: Sign(secret, now, body),
+			body:      body,
+			want:      ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
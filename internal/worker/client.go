@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"context"
+)
+
+// Client enqueues jobs from processes that do not run their handlers, such as the API.
+// Unlike Runtime.Enqueue it does not know the registered queue or retry limit of a
+// job type, so both are given by the caller.
+type Client struct {
+	queue Queue
+}
+
+// NewClient creates a new enqueue-only client
+func NewClient(queue Queue) *Client {
+	return &Client{queue: queue}
+}
+
+// Enqueue puts a job on queue, use WithMaxRetries to allow retries
+func (c *Client) Enqueue(ctx context.Context, queue, jobType string, payload interface{}, opts ...EnqueueOption) (*Job, error) {
+	job, err := NewJob(ctx, jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+	job.Queue = queue
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := c.queue.Enqueue(ctx, job); err != nil {
+		return job, err
+	}
+	return job, nil
+}
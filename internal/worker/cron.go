@@ -0,0 +1,326 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/lease"
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Run results recorded for periodic tasks
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunSkipped   = "skipped"
+)
+
+// Redis keys used by Scheduler:
+//
+//	worker:cron:tick:<task>:<unix>  STRING claims one tick for one replica
+//	worker:cron:lease:<task>        STRING lease held while the task runs
+//	worker:cron:runs:<task>         LIST   recent run records, newest first
+const cronPrefix = "worker:cron:"
+
+// TaskFunc is the body of a periodic task
+type TaskFunc func(ctx context.Context) error
+
+// PeriodicTask describes a registered cron task
+type PeriodicTask struct {
+	Name     string    `json:"name"`
+	Spec     string    `json:"spec"`
+	Timezone string    `json:"timezone"`
+	NextRun  time.Time `json:"next_run"`
+
+	schedule cron.Schedule
+	run      TaskFunc
+	timeout  time.Duration
+}
+
+// TaskRun records one execution of a periodic task
+type TaskRun struct {
+	Task       string     `json:"task"`
+	Tick       time.Time  `json:"tick"`
+	Instance   string     `json:"instance"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// SchedulerOptions configures the cron scheduler
+type SchedulerOptions struct {
+	// Location is used for specs without a CRON_TZ= prefix
+	Location *time.Location
+	// LeaseTTL is how long a task lease lives between renewals
+	LeaseTTL time.Duration
+	// History is how many runs are kept per task
+	History int64
+}
+
+// Scheduler runs periodic tasks on standard cron expressions.
+// Every replica evaluates the schedule, but a tick is claimed in Redis so
+// only one replica runs each task per tick, and a lease keeps runs of the
+// same task from overlapping.
+type Scheduler struct {
+	client   *redis.Client
+	logger   *logger.Logger
+	opts     SchedulerOptions
+	parser   cron.Parser
+	instance string
+
+	mu    sync.Mutex
+	tasks map[string]*PeriodicTask
+	wg    sync.WaitGroup
+}
+
+// NewScheduler creates a new cron scheduler
+func NewScheduler(client *redis.Client, logger *logger.Logger, opts SchedulerOptions) *Scheduler {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		client:   client,
+		logger:   logger,
+		opts:     opts,
+		parser:   cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		tasks:    make(map[string]*PeriodicTask),
+	}
+}
+
+// Add registers a task. spec is a five-field cron expression or descriptor
+// such as @daily, optionally prefixed with CRON_TZ=<zone>.
+func (s *Scheduler) Add(name, spec string, timeout time.Duration, run TaskFunc) error {
+	withZone := spec
+	if len(spec) < 8 || spec[:8] != "CRON_TZ=" {
+		withZone = "CRON_TZ=" + s.opts.Location.String() + " " + spec
+	}
+	schedule, err := s.parser.Parse(withZone)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for task %s: %w", spec, name, err)
+	}
+
+	timezone := s.opts.Location.String()
+	if spec, ok := schedule.(*cron.SpecSchedule); ok {
+		timezone = spec.Location.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[name] = &PeriodicTask{
+		Name:     name,
+		Spec:     spec,
+		Timezone: timezone,
+		schedule: schedule,
+		run:      run,
+		timeout:  timeout,
+	}
+	return nil
+}
+
+// Tasks returns every registered task with its next run time
+func (s *Scheduler) Tasks() []PeriodicTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]PeriodicTask, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, *task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks
+}
+
+// Task returns a registered task by name
+func (s *Scheduler) Task(name string) (PeriodicTask, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[name]
+	if !ok {
+		return PeriodicTask{}, false
+	}
+	return *task, true
+}
+
+// Runs returns the most recent runs of a task, newest first
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int64) ([]TaskRun, error) {
+	values, err := s.client.LRange(ctx, cronPrefix+"runs:"+name, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]TaskRun, 0, len(values))
+	for _, value := range values {
+		var run TaskRun
+		if err := json.Unmarshal([]byte(value), &run); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// Run fires tasks on schedule until ctx is cancelled, then waits for running tasks
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("Starting cron scheduler...")
+	defer s.wg.Wait()
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, task := range s.tasks {
+		task.NextRun = task.schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	for {
+		next := s.nextWake()
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.logger.Info("Cron scheduler stopped")
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		s.mu.Lock()
+		for _, task := range s.tasks {
+			if task.NextRun.After(now) {
+				continue
+			}
+			tick := task.NextRun
+			task.NextRun = task.schedule.Next(now)
+
+			s.wg.Add(1)
+			go func(task PeriodicTask, tick time.Time) {
+				defer s.wg.Done()
+				s.fire(ctx, task, tick)
+			}(*task, tick)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) nextWake() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := time.Now().Add(time.Minute)
+	for _, task := range s.tasks {
+		if task.NextRun.Before(next) {
+			next = task.NextRun
+		}
+	}
+	return next
+}
+
+// fire claims the tick and runs the task while holding its lease
+func (s *Scheduler) fire(ctx context.Context, task PeriodicTask, tick time.Time) {
+	tickKey := fmt.Sprintf("%stick:%s:%d", cronPrefix, task.Name, tick.Unix())
+	claimed, err := s.client.SetNX(ctx, tickKey, s.instance, 24*time.Hour).Result()
+	if err != nil {
+		s.logger.LogError(err, "cron_claim", map[string]interface{}{"task": task.Name})
+		return
+	}
+	if !claimed {
+		// Another replica owns this tick
+		return
+	}
+
+	run := TaskRun{
+		Task:      task.Name,
+		Tick:      tick.UTC(),
+		Instance:  s.instance,
+		StartedAt: time.Now().UTC(),
+	}
+
+	lock := lease.New(s.client, cronPrefix+"lease:"+task.Name, s.opts.LeaseTTL)
+	acquired, err := lock.Acquire(ctx)
+	if err != nil || !acquired {
+		run.Status = RunSkipped
+		run.Error = "previous run still holds the lease"
+		if err != nil {
+			run.Error = err.Error()
+		}
+		s.record(run)
+		return
+	}
+	defer lock.Release(context.Background())
+
+	taskCtx, cancel := context.WithTimeout(ctx, task.timeout)
+	defer cancel()
+	go lock.KeepAlive(taskCtx, func() {
+		s.logger.WithField("task", task.Name).Warn("Lost cron lease, cancelling task")
+		cancel()
+	})
+
+	taskCtx, span := tracing.Start(taskCtx, "cron "+task.Name, trace.WithAttributes(
+		attribute.String("cron.task", task.Name),
+		attribute.String("cron.tick", tick.UTC().Format(time.RFC3339)),
+	))
+	err = safeRun(taskCtx, task.run)
+	tracing.RecordError(span, err)
+	span.End()
+
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = RunSucceeded
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+		s.logger.LogError(err, "cron_task", map[string]interface{}{"task": task.Name})
+	}
+	s.record(run)
+}
+
+// record stores a run in the bounded history list
+func (s *Scheduler) record(run TaskRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return
+	}
+	key := cronPrefix + "runs:" + run.Task
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, data)
+		pipe.LTrim(ctx, key, 0, s.opts.History-1)
+		return nil
+	})
+	if err != nil {
+		s.logger.LogError(err, "cron_record", map[string]interface{}{"task": run.Task})
+	}
+
+	s.logger.WithFields(logger.Fields{
+		"task":        run.Task,
+		"status":      run.Status,
+		"duration_ms": run.DurationMs,
+	}).Info("Cron task finished")
+}
+
+func safeRun(ctx context.Context, run TaskFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ErrConcurrentUpdate is returned when the dead-letter list changed while it was being requeued
+var ErrConcurrentUpdate = errors.New("dead-letter list changed concurrently, try again")
+
+// QueueStats is a snapshot of one queue across all worker replicas
+type QueueStats struct {
+	Name      string `json:"name"`
+	Paused    bool   `json:"paused"`
+	Ready     int64  `json:"ready"`
+	Scheduled int64  `json:"scheduled"`
+	InFlight  int64  `json:"in_flight"`
+	Dead      int64  `json:"dead"`
+}
+
+// Failure records a failed job attempt
+type Failure struct {
+	JobID    string    `json:"job_id"`
+	Type     string    `json:"type"`
+	Queue    string    `json:"queue"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Dead     bool      `json:"dead"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Pause stops every replica from fetching jobs of queue, running jobs are not affected
+func (q *RedisQueue) Pause(ctx context.Context, queue string) error {
+	return q.client.SAdd(ctx, keyPaused, queue).Err()
+}
+
+// Resume lets replicas fetch jobs of a paused queue again
+func (q *RedisQueue) Resume(ctx context.Context, queue string) error {
+	return q.client.SRem(ctx, keyPaused, queue).Err()
+}
+
+// Stats returns the number of jobs in each state of queue
+func (q *RedisQueue) Stats(ctx context.Context, queue string) (*QueueStats, error) {
+	pipe := q.client.Pipeline()
+	paused := pipe.SIsMember(ctx, keyPaused, queue)
+	ready := pipe.LLen(ctx, readyKey(queue))
+	scheduled := pipe.ZCard(ctx, scheduledKey(queue))
+	inflight := pipe.ZCard(ctx, inflightKey(queue))
+	dead := pipe.LLen(ctx, deadKey(queue))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read stats of %s: %w", queue, err)
+	}
+
+	return &QueueStats{
+		Name:      queue,
+		Paused:    paused.Val(),
+		Ready:     ready.Val(),
+		Scheduled: scheduled.Val(),
+		InFlight:  inflight.Val(),
+		Dead:      dead.Val(),
+	}, nil
+}
+
+// DeadJobs returns up to limit dead-lettered jobs of queue, newest first
+func (q *RedisQueue) DeadJobs(ctx context.Context, queue string, limit int64) ([]Job, error) {
+	bodies, err := q.client.LRange(ctx, deadKey(queue), 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs of %s: %w", queue, err)
+	}
+
+	jobs := make([]Job, 0, len(bodies))
+	for _, body := range bodies {
+		var job Job
+		if err := json.Unmarshal([]byte(body), &job); err != nil {
+			return nil, fmt.Errorf("failed to decode dead job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// RequeueDead moves dead-lettered jobs of queue back to the ready list with a
+// fresh retry budget. An empty jobID requeues every dead job. It returns the
+// number of requeued jobs. Unique keys are not reclaimed.
+func (q *RedisQueue) RequeueDead(ctx context.Context, queue, jobID string) (int, error) {
+	requeued := 0
+	err := q.client.Watch(ctx, func(tx *redis.Tx) error {
+		bodies, err := tx.LRange(ctx, deadKey(queue), 0, -1).Result()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, body := range bodies {
+				var job Job
+				if err := json.Unmarshal([]byte(body), &job); err != nil {
+					return fmt.Errorf("failed to decode dead job: %w", err)
+				}
+				if jobID != "" && job.ID != jobID {
+					continue
+				}
+
+				job.Attempt = 0
+				job.RunAt = nil
+				requeuedBody, err := json.Marshal(job)
+				if err != nil {
+					return fmt.Errorf("failed to encode job: %w", err)
+				}
+
+				pipe.LRem(ctx, deadKey(queue), 1, body)
+				pipe.HSet(ctx, keyJobs, job.ID, requeuedBody)
+				pipe.LPush(ctx, readyKey(queue), job.ID)
+				requeued++
+			}
+			return nil
+		})
+		return err
+	}, deadKey(queue))
+	if errors.Is(err, redis.TxFailedErr) {
+		return 0, ErrConcurrentUpdate
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead jobs of %s: %w", queue, err)
+	}
+	return requeued, nil
+}
+
+// RecentFailures returns up to limit failed attempts across all queues, newest first
+func (q *RedisQueue) RecentFailures(ctx context.Context, limit int64) ([]Failure, error) {
+	bodies, err := q.client.LRange(ctx, keyFailures, 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent failures: %w", err)
+	}
+
+	failures := make([]Failure, 0, len(bodies))
+	for _, body := range bodies {
+		var failure Failure
+		if err := json.Unmarshal([]byte(body), &failure); err != nil {
+			return nil, fmt.Errorf("failed to decode failure: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+	return failures, nil
+}
+
+// recordFailure adds the failed attempt of job to the capped failure log
+func (q *RedisQueue) recordFailure(ctx context.Context, pipe redis.Pipeliner, job *Job, dead bool) {
+	// Retry is called with the attempt counter already advanced
+	attempts := job.Attempt
+	if dead {
+		attempts++
+	}
+
+	body, err := json.Marshal(Failure{
+		JobID:    job.ID,
+		Type:     job.Type,
+		Queue:    job.Queue,
+		Attempts: attempts,
+		Error:    job.LastError,
+		Dead:     dead,
+		FailedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	pipe.LPush(ctx, keyFailures, body)
+	pipe.LTrim(ctx, keyFailures, 0, maxFailures-1)
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-user-service/internal/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// DefaultQueue is used for job types registered without a queue
+const DefaultQueue = "default"
+
+// Job is a unit of background work stored in a queue
+type Job struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Queue      string            `json:"queue"`
+	Payload    json.RawMessage   `json:"payload"`
+	Attempt    int               `json:"attempt"`
+	MaxRetries int               `json:"max_retries"`
+	Trace      map[string]string `json:"trace,omitempty"`
+	EnqueuedAt time.Time         `json:"enqueued_at"`
+	LastError  string            `json:"last_error,omitempty"`
+
+	// RunAt delays the job until the given time
+	RunAt *time.Time `json:"run_at,omitempty"`
+	// UniqueKey prevents a second job with the same key while this one is pending
+	UniqueKey string `json:"unique_key,omitempty"`
+}
+
+// EnqueueOption customises a job before it is enqueued
+type EnqueueOption func(job *Job)
+
+// WithUniqueKey rejects the job with ErrDuplicateJob while another job holds key
+func WithUniqueKey(key string) EnqueueOption {
+	return func(job *Job) {
+		job.UniqueKey = key
+	}
+}
+
+// WithMaxRetries overrides the retry limit of the job type
+func WithMaxRetries(n int) EnqueueOption {
+	return func(job *Job) {
+		job.MaxRetries = n
+	}
+}
+
+// NewJob creates a job with a fresh ID and the payload encoded as JSON.
+// The trace context of ctx is captured so the worker span links back to the caller.
+func NewJob(ctx context.Context, jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", jobType, err)
+	}
+
+	return &Job{
+		ID:         uuid.NewString(),
+		Type:       jobType,
+		Payload:    data,
+		Trace:      tracing.Inject(ctx),
+		EnqueuedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Decode unmarshals the job payload into v
+func (j *Job) Decode(v interface{}) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", j.Type, err)
+	}
+	return nil
+}
+
+// Handler processes a job, returning an error to have it retried
+type Handler func(ctx context.Context, job *Job) error
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered without further retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
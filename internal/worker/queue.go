@@ -0,0 +1,308 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ErrDuplicateJob is returned when a job with the same unique key is already queued
+var ErrDuplicateJob = errors.New("duplicate job")
+
+// Queue stores jobs waiting to run, scheduled, running (leased) and dead-lettered
+type Queue interface {
+	// Enqueue makes job ready to run on job.Queue, or schedules it when job.RunAt is in the future.
+	// When job.UniqueKey is taken by another job it returns ErrDuplicateJob and sets job.ID to the existing job.
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue leases the next ready job, returning nil when the queue is empty
+	Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error)
+	// Ack removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// Retry puts a failed job back on its queue after delay
+	Retry(ctx context.Context, job *Job, delay time.Duration) error
+	// Kill moves a job that exhausted its retries to the dead-letter list
+	Kill(ctx context.Context, job *Job) error
+	// Cancel removes a job that has not started yet, returning false when it is unknown or running
+	Cancel(ctx context.Context, jobID string) (bool, error)
+	// Promote moves scheduled jobs that are due to the ready list
+	Promote(ctx context.Context, queue string) (int, error)
+	// Reap returns jobs whose lease expired, e.g. after a worker crash, to their queue
+	Reap(ctx context.Context, queue string) (int, error)
+}
+
+// Redis keys used by RedisQueue:
+//
+//	worker:jobs                HASH   job ID -> job JSON
+//	worker:queue:<q>           LIST   ready job IDs, popped from the right
+//	worker:queue:<q>:scheduled ZSET   delayed job IDs scored by run time (unix ms)
+//	worker:queue:<q>:inflight  ZSET   leased job IDs scored by lease deadline (unix ms)
+//	worker:queue:<q>:dead      LIST   dead-lettered job JSON
+//	worker:unique:<key>        STRING ID of the job holding a unique key
+//	worker:paused              SET    names of paused queues
+//	worker:failures            LIST   recent failure JSON, newest first
+const (
+	keyJobs      = "worker:jobs"
+	keyPaused    = "worker:paused"
+	keyFailures  = "worker:failures"
+	queuePrefix  = "worker:queue:"
+	uniquePrefix = "worker:unique:"
+
+	// uniqueTTL bounds how long a unique key outlives the job run time,
+	// in case the holder is lost without being acknowledged
+	uniqueTTL = 24 * time.Hour
+
+	// maxFailures caps the recent failure log
+	maxFailures = 100
+)
+
+func readyKey(queue string) string     { return queuePrefix + queue }
+func scheduledKey(queue string) string { return queuePrefix + queue + ":scheduled" }
+func inflightKey(queue string) string  { return queuePrefix + queue + ":inflight" }
+func deadKey(queue string) string      { return queuePrefix + queue + ":dead" }
+func uniqueKey(key string) string      { return uniquePrefix + key }
+
+// enqueueScript stores a job and makes it ready or scheduled, honouring its unique key
+var enqueueScript = redis.NewScript(`
+if KEYS[4] ~= '' then
+	local existing = redis.call('GET', KEYS[4])
+	if existing then
+		return existing
+	end
+	redis.call('SET', KEYS[4], ARGV[1], 'PX', ARGV[4])
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+if ARGV[3] == '0' then
+	redis.call('LPUSH', KEYS[2], ARGV[1])
+else
+	redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+end
+return ARGV[1]
+`)
+
+// dequeueScript pops a ready ID, leases it and returns the job body.
+// Nothing is popped while the queue is paused.
+var dequeueScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[4], ARGV[2]) == 1 then
+	return false
+end
+local id = redis.call('RPOP', KEYS[1])
+while id do
+	local body = redis.call('HGET', KEYS[3], id)
+	if body then
+		redis.call('ZADD', KEYS[2], ARGV[1], id)
+		return body
+	end
+	-- the job was cancelled after it was queued
+	id = redis.call('RPOP', KEYS[1])
+end
+return false
+`)
+
+// promoteScript moves due scheduled jobs to the ready list.
+// Running it as one script keeps a job from being promoted twice by concurrent replicas.
+var promoteScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('LPUSH', KEYS[2], id)
+end
+return #ids
+`)
+
+// reapScript moves expired leases back to the front of the ready list
+var reapScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('RPUSH', KEYS[1], id)
+end
+return #ids
+`)
+
+// cancelScript drops a job that is ready or scheduled, returns -1 when it is running
+var cancelScript = redis.NewScript(`
+local body = redis.call('HGET', KEYS[1], ARGV[1])
+if not body then
+	return 0
+end
+local job = cjson.decode(body)
+local queue = ARGV[2] .. job.queue
+if redis.call('ZSCORE', queue .. ':inflight', ARGV[1]) then
+	return -1
+end
+redis.call('ZREM', queue .. ':scheduled', ARGV[1])
+redis.call('LREM', queue, 0, ARGV[1])
+redis.call('HDEL', KEYS[1], ARGV[1])
+if type(job.unique_key) == 'string' and job.unique_key ~= '' then
+	local key = ARGV[3] .. job.unique_key
+	if redis.call('GET', key) == ARGV[1] then
+		redis.call('DEL', key)
+	end
+end
+return 1
+`)
+
+// releaseScript deletes a unique key only while it still belongs to the job
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisQueue is a Queue backed by Redis lists, a hash and sorted sets
+type RedisQueue struct {
+	client *redis.Client
+}
+
+// NewRedisQueue creates a new Redis job queue
+func NewRedisQueue(client *redis.Client) *RedisQueue {
+	return &RedisQueue{client: client}
+}
+
+// Enqueue implements Queue
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	runAt := "0"
+	ttl := uniqueTTL
+	if job.RunAt != nil && job.RunAt.After(time.Now()) {
+		runAt = strconv.FormatInt(job.RunAt.UnixMilli(), 10)
+		ttl += time.Until(*job.RunAt)
+	}
+	unique := ""
+	if job.UniqueKey != "" {
+		unique = uniqueKey(job.UniqueKey)
+	}
+
+	id, err := enqueueScript.Run(ctx, q.client,
+		[]string{keyJobs, readyKey(job.Queue), scheduledKey(job.Queue), unique},
+		job.ID, body, runAt, ttl.Milliseconds(),
+	).Text()
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job %s: %w", job.ID, err)
+	}
+	if id != job.ID {
+		job.ID = id
+		return ErrDuplicateJob
+	}
+	return nil
+}
+
+// Dequeue implements Queue
+func (q *RedisQueue) Dequeue(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	deadline := time.Now().Add(lease).UnixMilli()
+	body, err := dequeueScript.Run(ctx, q.client,
+		[]string{readyKey(queue), inflightKey(queue), keyJobs, keyPaused},
+		strconv.FormatInt(deadline, 10), queue,
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue from %s: %w", queue, err)
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(body), &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// Ack implements Queue
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, inflightKey(job.Queue), job.ID)
+		pipe.HDel(ctx, keyJobs, job.ID)
+		q.release(ctx, pipe, job)
+		return nil
+	})
+	return err
+}
+
+// Retry implements Queue
+func (q *RedisQueue) Retry(ctx context.Context, job *Job, delay time.Duration) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, inflightKey(job.Queue), job.ID)
+		pipe.HSet(ctx, keyJobs, job.ID, body)
+		if delay > 0 {
+			pipe.ZAdd(ctx, scheduledKey(job.Queue), redis.Z{
+				Score:  float64(time.Now().Add(delay).UnixMilli()),
+				Member: job.ID,
+			})
+		} else {
+			pipe.LPush(ctx, readyKey(job.Queue), job.ID)
+		}
+		q.recordFailure(ctx, pipe, job, false)
+		return nil
+	})
+	return err
+}
+
+// Kill implements Queue
+func (q *RedisQueue) Kill(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, inflightKey(job.Queue), job.ID)
+		pipe.HDel(ctx, keyJobs, job.ID)
+		pipe.LPush(ctx, deadKey(job.Queue), body)
+		q.release(ctx, pipe, job)
+		q.recordFailure(ctx, pipe, job, true)
+		return nil
+	})
+	return err
+}
+
+// Cancel implements Queue
+func (q *RedisQueue) Cancel(ctx context.Context, jobID string) (bool, error) {
+	result, err := cancelScript.Run(ctx, q.client, []string{keyJobs}, jobID, queuePrefix, uniquePrefix).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel job %s: %w", jobID, err)
+	}
+	return result == 1, nil
+}
+
+// Promote implements Queue
+func (q *RedisQueue) Promote(ctx context.Context, queue string) (int, error) {
+	return promoteScript.Run(ctx, q.client,
+		[]string{scheduledKey(queue), readyKey(queue)},
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	).Int()
+}
+
+// Reap implements Queue
+func (q *RedisQueue) Reap(ctx context.Context, queue string) (int, error) {
+	return reapScript.Run(ctx, q.client,
+		[]string{readyKey(queue), inflightKey(queue)},
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	).Int()
+}
+
+// release frees the job's unique key so an identical job can be enqueued again
+func (q *RedisQueue) release(ctx context.Context, pipe redis.Pipeliner, job *Job) {
+	if job.UniqueKey == "" {
+		return
+	}
+	releaseScript.Eval(ctx, pipe, []string{uniqueKey(job.UniqueKey)}, job.ID)
+}
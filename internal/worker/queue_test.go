@@ -0,0 +1,353 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+func testJob(t *testing.T, opts ...EnqueueOption) *Job {
+	t.Helper()
+
+	job, err := NewJob(context.Background(), "send_email", map[string]string{"to": "user@example.com"})
+	if err != nil {
+		t.Fatalf("NewJob() error = %v", err)
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	return job
+}
+
+func enqueue(t *testing.T, q *RedisQueue, job *Job) {
+	t.Helper()
+	if err := q.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+}
+
+func dequeue(t *testing.T, q *RedisQueue, queue string) *Job {
+	t.Helper()
+	job, err := q.Dequeue(context.Background(), queue, time.Minute)
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	return job
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	first, second := testJob(t), testJob(t)
+	enqueue(t, q, first)
+	enqueue(t, q, second)
+	if first.Queue != DefaultQueue {
+		t.Errorf("Queue = %q, want %q", first.Queue, DefaultQueue)
+	}
+
+	before := time.Now()
+	got := dequeue(t, q, DefaultQueue)
+	if got == nil || got.ID != first.ID {
+		t.Fatalf("Dequeue() = %v, want the first job", got)
+	}
+	if string(got.Payload) != string(first.Payload) || got.Type != first.Type {
+		t.Errorf("Dequeue() = %+v, want %+v", got, first)
+	}
+
+	// The job is leased until its deadline
+	score, err := q.client.ZScore(ctx, inflightKey(DefaultQueue), first.ID).Result()
+	if err != nil {
+		t.Fatalf("job is not in flight: %v", err)
+	}
+	if lease := time.UnixMilli(int64(score)).Sub(before); lease < 59*time.Second || lease > time.Minute+time.Second {
+		t.Errorf("lease = %s, want about a minute", lease)
+	}
+
+	if got := dequeue(t, q, DefaultQueue); got == nil || got.ID != second.ID {
+		t.Fatalf("Dequeue() = %v, want the second job", got)
+	}
+	if got := dequeue(t, q, DefaultQueue); got != nil {
+		t.Errorf("Dequeue() on an empty queue = %v, want nil", got)
+	}
+
+	if err := q.Ack(ctx, first); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	if q.client.HExists(ctx, keyJobs, first.ID).Val() || q.client.ZScore(ctx, inflightKey(DefaultQueue), first.ID).Err() != redis.Nil {
+		t.Error("Ack() left the job behind")
+	}
+}
+
+func TestEnqueueUnique(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	first := testJob(t, WithUniqueKey("welcome:1"))
+	enqueue(t, q, first)
+
+	duplicate := testJob(t, WithUniqueKey("welcome:1"))
+	if err := q.Enqueue(ctx, duplicate); !errors.Is(err, ErrDuplicateJob) {
+		t.Fatalf("Enqueue() of a duplicate error = %v, want ErrDuplicateJob", err)
+	}
+	if duplicate.ID != first.ID {
+		t.Errorf("duplicate ID = %s, want the existing job %s", duplicate.ID, first.ID)
+	}
+	if n := q.client.LLen(ctx, readyKey(DefaultQueue)).Val(); n != 1 {
+		t.Errorf("ready = %d, want 1", n)
+	}
+	if ttl := q.client.PTTL(ctx, uniqueKey("welcome:1")).Val(); ttl <= 0 || ttl > uniqueTTL {
+		t.Errorf("unique key TTL = %s, want up to %s", ttl, uniqueTTL)
+	}
+
+	// Finishing the job releases its key
+	job := dequeue(t, q, DefaultQueue)
+	if err := q.Ack(ctx, job); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	enqueue(t, q, testJob(t, WithUniqueKey("welcome:1")))
+}
+
+func TestReleaseKeepsForeignUniqueKey(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	job := testJob(t, WithUniqueKey("welcome:1"))
+	enqueue(t, q, job)
+	job = dequeue(t, q, DefaultQueue)
+
+	// The key expired and another job took it while this one was running
+	q.client.Set(ctx, uniqueKey("welcome:1"), "other", 0)
+
+	if err := q.Kill(ctx, job); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	if got := q.client.Get(ctx, uniqueKey("welcome:1")).Val(); got != "other" {
+		t.Errorf("unique key = %q, want it kept for the other job", got)
+	}
+	if n := q.client.LLen(ctx, deadKey(DefaultQueue)).Val(); n != 1 {
+		t.Errorf("dead = %d, want 1", n)
+	}
+}
+
+func TestEnqueueScheduledAndPromote(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	runAt := time.Now().Add(time.Hour)
+	job := testJob(t, WithUniqueKey("digest"))
+	job.RunAt = &runAt
+	enqueue(t, q, job)
+
+	if got := dequeue(t, q, DefaultQueue); got != nil {
+		t.Fatalf("Dequeue() returned the scheduled job %s", got.ID)
+	}
+	score, err := q.client.ZScore(ctx, scheduledKey(DefaultQueue), job.ID).Result()
+	if err != nil || int64(score) != runAt.UnixMilli() {
+		t.Fatalf("scheduled score = %v (%v), want %d", score, err, runAt.UnixMilli())
+	}
+	// The unique key outlives the wait for the run time
+	if ttl := q.client.PTTL(ctx, uniqueKey("digest")).Val(); ttl <= uniqueTTL {
+		t.Errorf("unique key TTL = %s, want more than %s", ttl, uniqueTTL)
+	}
+
+	if n, err := q.Promote(ctx, DefaultQueue); err != nil || n != 0 {
+		t.Fatalf("Promote() = %d, %v, want nothing due", n, err)
+	}
+
+	q.client.ZAdd(ctx, scheduledKey(DefaultQueue), redis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: job.ID})
+	if n, err := q.Promote(ctx, DefaultQueue); err != nil || n != 1 {
+		t.Fatalf("Promote() = %d, %v, want 1", n, err)
+	}
+	if n := q.client.ZCard(ctx, scheduledKey(DefaultQueue)).Val(); n != 0 {
+		t.Errorf("scheduled = %d after promotion, want 0", n)
+	}
+	if n, _ := q.Promote(ctx, DefaultQueue); n != 0 {
+		t.Errorf("second Promote() = %d, want 0", n)
+	}
+	if got := dequeue(t, q, DefaultQueue); got == nil || got.ID != job.ID {
+		t.Errorf("Dequeue() = %v, want the promoted job", got)
+	}
+}
+
+func TestDequeuePaused(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	job := testJob(t)
+	enqueue(t, q, job)
+
+	if err := q.Pause(ctx, DefaultQueue); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if got := dequeue(t, q, DefaultQueue); got != nil {
+		t.Fatalf("Dequeue() on a paused queue = %v, want nil", got)
+	}
+	if n := q.client.LLen(ctx, readyKey(DefaultQueue)).Val(); n != 1 {
+		t.Errorf("ready = %d while paused, want the job kept", n)
+	}
+
+	if err := q.Resume(ctx, DefaultQueue); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if got := dequeue(t, q, DefaultQueue); got == nil || got.ID != job.ID {
+		t.Errorf("Dequeue() after resume = %v, want the job", got)
+	}
+}
+
+func TestDequeueSkipsRemovedJobs(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	gone, kept := testJob(t), testJob(t)
+	enqueue(t, q, gone)
+	enqueue(t, q, kept)
+	q.client.HDel(ctx, keyJobs, gone.ID)
+
+	if got := dequeue(t, q, DefaultQueue); got == nil || got.ID != kept.ID {
+		t.Fatalf("Dequeue() = %v, want the job after the removed one", got)
+	}
+	if q.client.ZScore(ctx, inflightKey(DefaultQueue), gone.ID).Err() != redis.Nil {
+		t.Error("the removed job was leased")
+	}
+}
+
+func TestReap(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	crashed, waiting := testJob(t), testJob(t)
+	enqueue(t, q, crashed)
+	dequeue(t, q, DefaultQueue)
+	enqueue(t, q, waiting)
+
+	if n, err := q.Reap(ctx, DefaultQueue); err != nil || n != 0 {
+		t.Fatalf("Reap() = %d, %v, want no expired lease", n, err)
+	}
+
+	q.client.ZAdd(ctx, inflightKey(DefaultQueue), redis.Z{Score: float64(time.Now().Add(-time.Second).UnixMilli()), Member: crashed.ID})
+	if n, err := q.Reap(ctx, DefaultQueue); err != nil || n != 1 {
+		t.Fatalf("Reap() = %d, %v, want 1", n, err)
+	}
+	if n := q.client.ZCard(ctx, inflightKey(DefaultQueue)).Val(); n != 0 {
+		t.Errorf("in flight = %d after reaping, want 0", n)
+	}
+
+	// Reaped jobs go ahead of jobs that were waiting behind them
+	if got := dequeue(t, q, DefaultQueue); got == nil || got.ID != crashed.ID {
+		t.Errorf("Dequeue() = %v, want the reaped job first", got)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	ready := testJob(t, WithUniqueKey("ready"))
+	enqueue(t, q, ready)
+
+	runAt := time.Now().Add(time.Hour)
+	scheduled := testJob(t)
+	scheduled.RunAt = &runAt
+	enqueue(t, q, scheduled)
+
+	running := testJob(t)
+	enqueue(t, q, running)
+	// Lease the running job, the ready one is in front of it
+	q.client.LRem(ctx, readyKey(DefaultQueue), 0, running.ID)
+	q.client.RPush(ctx, readyKey(DefaultQueue), running.ID)
+	dequeue(t, q, DefaultQueue)
+
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"ready", ready.ID, true},
+		{"scheduled", scheduled.ID, true},
+		{"running", running.ID, false},
+		{"unknown", "missing", false},
+		{"already cancelled", ready.ID, false},
+	}
+	for _, tt := range tests {
+		got, err := q.Cancel(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("Cancel(%s) error = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("Cancel(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if n := q.client.LLen(ctx, readyKey(DefaultQueue)).Val(); n != 0 {
+		t.Errorf("ready = %d, want 0", n)
+	}
+	if n := q.client.ZCard(ctx, scheduledKey(DefaultQueue)).Val(); n != 0 {
+		t.Errorf("scheduled = %d, want 0", n)
+	}
+	if q.client.Exists(ctx, uniqueKey("ready")).Val() != 0 {
+		t.Error("Cancel() kept the unique key")
+	}
+	if !q.client.HExists(ctx, keyJobs, running.ID).Val() {
+		t.Error("Cancel() removed the running job")
+	}
+	if got := dequeue(t, q, DefaultQueue); got != nil {
+		t.Errorf("Dequeue() = %s, want nothing left", got.ID)
+	}
+}
+
+func TestCancelKeepsForeignUniqueKey(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	job := testJob(t, WithUniqueKey("welcome:1"))
+	enqueue(t, q, job)
+	q.client.Set(ctx, uniqueKey("welcome:1"), "other", 0)
+
+	if ok, err := q.Cancel(ctx, job.ID); err != nil || !ok {
+		t.Fatalf("Cancel() = %v, %v, want true", ok, err)
+	}
+	if got := q.client.Get(ctx, uniqueKey("welcome:1")).Val(); got != "other" {
+		t.Errorf("unique key = %q, want it kept for the other job", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+
+	job := testJob(t)
+	enqueue(t, q, job)
+	job = dequeue(t, q, DefaultQueue)
+	job.Attempt = 1
+	job.LastError = "smtp timeout"
+
+	if err := q.Retry(ctx, job, time.Minute); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	if n := q.client.ZCard(ctx, inflightKey(DefaultQueue)).Val(); n != 0 {
+		t.Errorf("in flight = %d, want 0", n)
+	}
+	if n := q.client.ZCard(ctx, scheduledKey(DefaultQueue)).Val(); n != 1 {
+		t.Errorf("scheduled = %d, want the delayed retry", n)
+	}
+
+	failures, err := q.RecentFailures(ctx, 10)
+	if err != nil {
+		t.Fatalf("RecentFailures() error = %v", err)
+	}
+	if len(failures) != 1 || failures[0].Error != "smtp timeout" || failures[0].Dead {
+		t.Errorf("RecentFailures() = %+v, want one retried failure", failures)
+	}
+
+	if err := q.Retry(ctx, job, 0); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	got := dequeue(t, q, DefaultQueue)
+	if got == nil || got.Attempt != 1 || got.LastError != "smtp timeout" {
+		t.Errorf("Dequeue() = %+v, want the retried job with its attempt", got)
+	}
+}
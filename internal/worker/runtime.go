@@ -0,0 +1,398 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// QueueConfig configures how one queue is consumed
+type QueueConfig struct {
+	Name        string
+	Concurrency int
+	// Timeout is the deadline given to each job on this queue
+	Timeout time.Duration
+}
+
+// Options configures the runtime
+type Options struct {
+	// PollInterval is how long an idle worker waits before polling again
+	PollInterval time.Duration
+	// LeaseGrace is added to the job timeout when leasing a job
+	LeaseGrace time.Duration
+	// ReapInterval is how often expired leases are returned to their queue
+	ReapInterval time.Duration
+	// PromoteInterval is how often due scheduled jobs are moved to their queue
+	PromoteInterval time.Duration
+	// MaxRetries is used for job types registered without their own limit
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled on every attempt up to MaxRetryBackoff
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// DefaultOptions returns options suitable for most deployments
+func DefaultOptions() Options {
+	return Options{
+		PollInterval:    time.Second,
+		LeaseGrace:      30 * time.Second,
+		ReapInterval:    30 * time.Second,
+		PromoteInterval: time.Second,
+		MaxRetries:      3,
+		RetryBackoff:    10 * time.Second,
+		MaxRetryBackoff: 10 * time.Minute,
+	}
+}
+
+type registration struct {
+	handler    Handler
+	queue      string
+	maxRetries int
+}
+
+// InFlightJob describes a job currently being handled
+type InFlightJob struct {
+	Job       *Job      `json:"job"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Runtime consumes queues with a fixed pool of workers per queue
+type Runtime struct {
+	queue  Queue
+	logger *logger.Logger
+	opts   Options
+
+	queues   map[string]QueueConfig
+	handlers map[string]registration
+
+	// stopCtx stops fetching, jobCtx is only cancelled once the drain timeout expires
+	stopCtx    context.Context
+	stop       context.CancelFunc
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	inflight map[string]InFlightJob
+}
+
+// New creates a new worker runtime
+func New(queue Queue, logger *logger.Logger, opts Options) *Runtime {
+	return &Runtime{
+		queue:    queue,
+		logger:   logger,
+		opts:     opts,
+		queues:   make(map[string]QueueConfig),
+		handlers: make(map[string]registration),
+		inflight: make(map[string]InFlightJob),
+	}
+}
+
+// AddQueue declares a queue and how many jobs it runs concurrently
+func (r *Runtime) AddQueue(cfg QueueConfig) {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	r.queues[cfg.Name] = cfg
+}
+
+// Queues returns the names of the declared queues, sorted
+func (r *Runtime) Queues() []string {
+	names := make([]string, 0, len(r.queues))
+	for name := range r.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register binds a job type to its handler and queue.
+// maxRetries below zero uses Options.MaxRetries.
+func (r *Runtime) Register(jobType, queue string, maxRetries int, handler Handler) {
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	if maxRetries < 0 {
+		maxRetries = r.opts.MaxRetries
+	}
+	if _, ok := r.queues[queue]; !ok {
+		r.AddQueue(QueueConfig{Name: queue, Concurrency: 1, Timeout: time.Minute})
+	}
+	r.handlers[jobType] = registration{handler: handler, queue: queue, maxRetries: maxRetries}
+}
+
+// Enqueue creates a job of a registered type and puts it on its queue.
+// With a unique key that is taken it returns the existing job's ID and ErrDuplicateJob.
+func (r *Runtime) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (*Job, error) {
+	return r.enqueue(ctx, jobType, payload, nil, opts)
+}
+
+// EnqueueAt schedules a job of a registered type to run at the given time
+func (r *Runtime) EnqueueAt(ctx context.Context, jobType string, payload interface{}, at time.Time, opts ...EnqueueOption) (*Job, error) {
+	return r.enqueue(ctx, jobType, payload, &at, opts)
+}
+
+// EnqueueIn schedules a job of a registered type to run after delay
+func (r *Runtime) EnqueueIn(ctx context.Context, jobType string, payload interface{}, delay time.Duration, opts ...EnqueueOption) (*Job, error) {
+	return r.EnqueueAt(ctx, jobType, payload, time.Now().Add(delay), opts...)
+}
+
+// Cancel removes a queued or scheduled job, returning false when it is unknown or already running
+func (r *Runtime) Cancel(ctx context.Context, jobID string) (bool, error) {
+	return r.queue.Cancel(ctx, jobID)
+}
+
+func (r *Runtime) enqueue(ctx context.Context, jobType string, payload interface{}, at *time.Time, opts []EnqueueOption) (*Job, error) {
+	reg, ok := r.handlers[jobType]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type %q", jobType)
+	}
+
+	job, err := NewJob(ctx, jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+	job.Queue = reg.queue
+	job.MaxRetries = reg.maxRetries
+	if at != nil {
+		runAt := at.UTC()
+		job.RunAt = &runAt
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := r.queue.Enqueue(ctx, job); err != nil {
+		return job, err
+	}
+	return job, nil
+}
+
+// Start launches the workers of every queue
+func (r *Runtime) Start(ctx context.Context) {
+	r.stopCtx, r.stop = context.WithCancel(ctx)
+	r.jobCtx, r.cancelJobs = context.WithCancel(context.WithoutCancel(ctx))
+
+	for _, cfg := range r.queues {
+		r.logger.WithFields(logger.Fields{
+			"queue":       cfg.Name,
+			"concurrency": cfg.Concurrency,
+			"timeout":     cfg.Timeout.String(),
+		}).Info("Starting queue workers")
+
+		for i := 0; i < cfg.Concurrency; i++ {
+			r.wg.Add(1)
+			go r.work(cfg)
+		}
+
+		r.wg.Add(1)
+		go r.maintain(cfg)
+	}
+}
+
+// Shutdown stops fetching jobs and waits for in-flight jobs until ctx expires.
+// Jobs still running at that point are cancelled and returned as abandoned;
+// their leases expire and they are picked up again by the next worker.
+func (r *Runtime) Shutdown(ctx context.Context) []InFlightJob {
+	if r.stop == nil {
+		return nil
+	}
+	r.stop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancelJobs()
+		return nil
+	case <-ctx.Done():
+	}
+
+	abandoned := r.InFlight()
+	r.cancelJobs()
+	for _, job := range abandoned {
+		r.logger.WithFields(logger.Fields{
+			"job_id":     job.Job.ID,
+			"job_type":   job.Job.Type,
+			"queue":      job.Job.Queue,
+			"started_at": job.StartedAt,
+		}).Warn("Abandoned in-flight job on shutdown")
+	}
+	return abandoned
+}
+
+// InFlight returns the jobs currently being handled, oldest first
+func (r *Runtime) InFlight() []InFlightJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]InFlightJob, 0, len(r.inflight))
+	for _, job := range r.inflight {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+func (r *Runtime) work(cfg QueueConfig) {
+	defer r.wg.Done()
+
+	for r.stopCtx.Err() == nil {
+		job, err := r.queue.Dequeue(r.stopCtx, cfg.Name, cfg.Timeout+r.opts.LeaseGrace)
+		if err != nil && r.stopCtx.Err() == nil {
+			r.logger.LogError(err, "worker_dequeue", map[string]interface{}{"queue": cfg.Name})
+		}
+		if job == nil {
+			select {
+			case <-r.stopCtx.Done():
+			case <-time.After(r.opts.PollInterval):
+			}
+			continue
+		}
+
+		r.run(cfg, job)
+	}
+}
+
+// maintain promotes due scheduled jobs and requeues expired leases of one queue
+func (r *Runtime) maintain(cfg QueueConfig) {
+	defer r.wg.Done()
+
+	promote := time.NewTicker(r.opts.PromoteInterval)
+	defer promote.Stop()
+	reap := time.NewTicker(r.opts.ReapInterval)
+	defer reap.Stop()
+
+	for {
+		select {
+		case <-r.stopCtx.Done():
+			return
+		case <-promote.C:
+			if _, err := r.queue.Promote(r.stopCtx, cfg.Name); err != nil && r.stopCtx.Err() == nil {
+				r.logger.LogError(err, "worker_promote", map[string]interface{}{"queue": cfg.Name})
+			}
+		case <-reap.C:
+			n, err := r.queue.Reap(r.stopCtx, cfg.Name)
+			if err != nil && r.stopCtx.Err() == nil {
+				r.logger.LogError(err, "worker_reap", map[string]interface{}{"queue": cfg.Name})
+			}
+			if n > 0 {
+				r.logger.WithField("queue", cfg.Name).Warn(fmt.Sprintf("Requeued %d job(s) with expired leases", n))
+			}
+		}
+	}
+}
+
+func (r *Runtime) run(cfg QueueConfig, job *Job) {
+	r.mu.Lock()
+	r.inflight[job.ID] = InFlightJob{Job: job, StartedAt: time.Now().UTC()}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.inflight, job.ID)
+		r.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(r.jobCtx, cfg.Timeout)
+	defer cancel()
+
+	ctx, span := tracing.StartJob(ctx, job.Trace, "job "+job.Type,
+		attribute.String("job.id", job.ID),
+		attribute.String("job.queue", job.Queue),
+		attribute.Int("job.attempt", job.Attempt),
+	)
+	defer span.End()
+
+	start := time.Now()
+	err := r.handle(ctx, job)
+	tracing.RecordError(span, err)
+	r.logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"queue":    job.Queue,
+		"attempt":  job.Attempt,
+		"duration": time.Since(start).Milliseconds(),
+	}).Debug("Job finished")
+
+	// Use a fresh context so the result is stored even while shutting down
+	storeCtx, storeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer storeCancel()
+
+	if err == nil {
+		if err := r.queue.Ack(storeCtx, job); err != nil {
+			r.logger.LogError(err, "worker_ack", map[string]interface{}{"job_id": job.ID})
+		}
+		return
+	}
+
+	if r.jobCtx.Err() != nil {
+		// Abandoned during shutdown, the lease expiry requeues it
+		return
+	}
+
+	job.LastError = err.Error()
+	if job.Attempt < job.MaxRetries && !IsPermanent(err) {
+		job.Attempt++
+		if err := r.queue.Retry(storeCtx, job, r.backoff(job.Attempt)); err != nil {
+			r.logger.LogError(err, "worker_retry", map[string]interface{}{"job_id": job.ID})
+		}
+		return
+	}
+
+	r.logger.LogError(err, "worker_job_dead", map[string]interface{}{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"queue":    job.Queue,
+		"attempts": job.Attempt + 1,
+	})
+	if err := r.queue.Kill(storeCtx, job); err != nil {
+		r.logger.LogError(err, "worker_kill", map[string]interface{}{"job_id": job.ID})
+	}
+}
+
+// backoff returns the exponential delay before the given retry (1-based)
+func (r *Runtime) backoff(retry int) time.Duration {
+	delay := r.opts.RetryBackoff
+	for i := 1; i < retry && delay < r.opts.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.opts.MaxRetryBackoff {
+		delay = r.opts.MaxRetryBackoff
+	}
+	return delay
+}
+
+// handle runs the registered handler, turning panics into errors
+func (r *Runtime) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("job panic: %v", rec)
+			r.logger.LogError(err, "worker_job_panic", map[string]interface{}{
+				"job_id":   job.ID,
+				"job_type": job.Type,
+				"stack":    string(debug.Stack()),
+			})
+		}
+	}()
+
+	reg, ok := r.handlers[job.Type]
+	if !ok {
+		// Retrying cannot help until a release registers the type
+		return Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	}
+	return reg.handler(ctx, job)
+}
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-user-service/internal/pkg/logger"
+	"go-user-service/internal/pkg/tracing"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestQueue(t *testing.T) (*RedisQueue, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewRedisQueue(client), server
+}
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.PollInterval = 10 * time.Millisecond
+	opts.PromoteInterval = 10 * time.Millisecond
+	return opts
+}
+
+func TestRuntimePropagatesTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	queue, _ := newTestQueue(t)
+	runtime := New(queue, logger.New("error", "test"), testOptions())
+
+	handled := make(chan trace.SpanContext, 1)
+	runtime.Register("traced", "", 0, func(ctx context.Context, job *Job) error {
+		handled <- trace.SpanContextFromContext(ctx)
+		return nil
+	})
+
+	ctx, producer := tracing.Start(context.Background(), "POST /users")
+	job, err := runtime.Enqueue(ctx, "traced", map[string]string{"hello": "world"})
+	producer.End()
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if job.Trace["traceparent"] == "" {
+		t.Fatalf("job.Trace = %v, want a traceparent", job.Trace)
+	}
+
+	runtime.Start(context.Background())
+	var consumer trace.SpanContext
+	select {
+	case consumer = <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not handled")
+	}
+	runtime.Shutdown(context.Background())
+
+	if consumer.TraceID() != producer.SpanContext().TraceID() {
+		t.Errorf("handler trace ID = %s, want the producer's %s", consumer.TraceID(), producer.SpanContext().TraceID())
+	}
+
+	var span sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "job traced" {
+			span = s
+		}
+	}
+	if span == nil {
+		t.Fatal("job span not recorded")
+	}
+	if span.SpanContext().SpanID() != consumer.SpanID() {
+		t.Error("the handler context does not carry the job span")
+	}
+	if span.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("kind = %v, want consumer", span.SpanKind())
+	}
+	if span.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("parent = %s, want the enqueuing span %s", span.Parent().SpanID(), producer.SpanContext().SpanID())
+	}
+	if links := span.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("links = %v, want one link to the enqueuing span", links)
+	}
+}
+
+func TestRuntimeDeadLettersUnknownJobType(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	runtime := New(queue, logger.New("panic", "test"), testOptions())
+	runtime.AddQueue(QueueConfig{Name: DefaultQueue, Concurrency: 1, Timeout: time.Second})
+
+	// Enqueued by a release that registered a type this one no longer knows
+	job, err := NewJob(context.Background(), "retired", nil)
+	if err != nil {
+		t.Fatalf("NewJob() error = %v", err)
+	}
+	job.MaxRetries = 3
+	if err := queue.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	runtime.Start(context.Background())
+	defer runtime.Shutdown(context.Background())
+
+	var dead []Job
+	deadline := time.Now().Add(5 * time.Second)
+	for len(dead) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if dead, err = queue.DeadJobs(context.Background(), DefaultQueue, 10); err != nil {
+			t.Fatalf("DeadJobs() error = %v", err)
+		}
+	}
+	if len(dead) != 1 || dead[0].ID != job.ID {
+		t.Fatalf("DeadJobs() = %v, want the unknown job", dead)
+	}
+	if dead[0].Attempt != 0 {
+		t.Errorf("Attempt = %d, want it dead-lettered without retries", dead[0].Attempt)
+	}
+}
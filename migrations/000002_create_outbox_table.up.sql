@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id              UUID PRIMARY KEY,
+    aggregate_type  VARCHAR(64)  NOT NULL,
+    aggregate_id    VARCHAR(64)  NOT NULL,
+    event_type      VARCHAR(128) NOT NULL,
+    payload         JSONB        NOT NULL,
+    headers         JSONB        NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ,
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    last_error      TEXT         NOT NULL DEFAULT ''
+);
+
+-- The relay only scans undelivered rows in creation order
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (created_at) WHERE delivered_at IS NULL;
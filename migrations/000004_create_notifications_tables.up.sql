@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key         VARCHAR(128) NOT NULL,
+    category    VARCHAR(32)  NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    body        TEXT         NOT NULL DEFAULT '',
+    data        JSONB        NOT NULL DEFAULT '{}',
+    read_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- key is the source event ID, it makes redelivered events idempotent
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_user_key ON notifications (user_id, key);
+CREATE INDEX IF NOT EXISTS notifications_inbox_idx ON notifications (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id     BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category    VARCHAR(32) NOT NULL,
+    channel     VARCHAR(32) NOT NULL,
+    enabled     BOOLEAN     NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category, channel)
+);
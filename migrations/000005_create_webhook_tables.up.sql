@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id               BIGSERIAL PRIMARY KEY,
+    url              TEXT         NOT NULL,
+    description      VARCHAR(255) NOT NULL DEFAULT '',
+    secret           VARCHAR(128) NOT NULL,
+    event_types      JSONB        NOT NULL DEFAULT '[]',
+    enabled          BOOLEAN      NOT NULL DEFAULT TRUE,
+    failure_count    INTEGER      NOT NULL DEFAULT 0,
+    disabled_at      TIMESTAMPTZ,
+    disabled_reason  TEXT         NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id                BIGSERIAL PRIMARY KEY,
+    endpoint_id       BIGINT       NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    event_id          VARCHAR(64)  NOT NULL,
+    event_type        VARCHAR(128) NOT NULL,
+    attempt           INTEGER      NOT NULL,
+    request_url       TEXT         NOT NULL,
+    request_headers   JSONB        NOT NULL DEFAULT '{}',
+    request_body      TEXT         NOT NULL,
+    response_status   INTEGER      NOT NULL DEFAULT 0,
+    response_headers  JSONB        NOT NULL DEFAULT '{}',
+    response_body     TEXT         NOT NULL DEFAULT '',
+    error             TEXT         NOT NULL DEFAULT '',
+    success           BOOLEAN      NOT NULL,
+    duration_ms       BIGINT       NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_endpoint_idx ON webhook_deliveries (endpoint_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_event_idx ON webhook_deliveries (event_id);
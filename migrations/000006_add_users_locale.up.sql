@@ -0,0 +1,2 @@
+-- Preferred language of API messages and emails, empty follows Accept-Language
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';